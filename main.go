@@ -23,6 +23,9 @@ func main() {
 
 	s, err := tss.Secret(1)
 
+	// log out before log.Fatal, which exits without running deferred calls
+	tss.Close()
+
 	if err != nil {
 		log.Fatal("Error calling server.Secret", err)
 	}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// accessRequestResource is the HTTP URL path component for the secret access requests resource
+const accessRequestResource = "secret-access-requests"
+
+// The statuses of an access request
+const (
+	AccessRequestPending  = "Pending"
+	AccessRequestApproved = "Approved"
+	AccessRequestDenied   = "Denied"
+	AccessRequestCanceled = "Canceled"
+	AccessRequestExpired  = "Expired"
+)
+
+// AccessRequest is a request to view a secret that requires approval
+type AccessRequest struct {
+	SecretAccessRequestID, SecretID int
+	RequestingUserID                int
+	RequestingUserDisplayName       string
+	RequestComment, ResponseComment string
+	Status                          string
+	StartDate, ExpirationDate       Time
+}
+
+// AccessRequestOptions give the reason for an access request and for how
+// long, from the Start (or now, if it is zero), access is wanted
+type AccessRequestOptions struct {
+	Reason   string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// ApprovalRequiredError is returned by Secret when the secret cannot be read
+// without an approved access request; see RequestAccess
+type ApprovalRequiredError struct {
+	SecretID int
+	Err      error
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	return fmt.Sprintf("secret %d requires an approved access request: %s", e.SecretID, e.Err)
+}
+
+func (e *ApprovalRequiredError) Unwrap() error {
+	return e.Err
+}
+
+// AccessRequestDeniedError is returned by WaitForAccessApproval when the
+// request is resolved without being approved
+type AccessRequestDeniedError struct {
+	Request *AccessRequest
+}
+
+func (e *AccessRequestDeniedError) Error() string {
+	return fmt.Sprintf("the access request %d for secret %d was %s: %s", e.Request.SecretAccessRequestID,
+		e.Request.SecretID, strings.ToLower(e.Request.Status), e.Request.ResponseComment)
+}
+
+// requiresApproval wraps the error from reading the secret with id in an
+// ApprovalRequiredError if it means that the read is pending approval
+func requiresApproval(id int, err error) error {
+	responseError, ok := err.(*ResponseError)
+
+	if !ok || responseError.StatusCode != http.StatusBadRequest && responseError.StatusCode != http.StatusForbidden {
+		return err
+	}
+	body := strings.ToLower(responseError.Body)
+
+	if strings.Contains(body, "approval") || strings.Contains(body, "access request") {
+		return &ApprovalRequiredError{SecretID: id, Err: err}
+	}
+	return err
+}
+
+// RequestAccess submits a request for access to the secret with id. The
+// options' Duration must be positive.
+func (s Server) RequestAccess(id int, options AccessRequestOptions) (*AccessRequest, error) {
+	if options.Duration <= 0 {
+		return nil, fmt.Errorf("the duration of the access request, %s, is not positive", options.Duration)
+	}
+
+	start := options.Start
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	input := struct {
+		SecretID                  int
+		RequestComment            string
+		StartDate, ExpirationDate Time
+	}{id, options.Reason, Time{start}, Time{start.Add(options.Duration)}}
+
+	request := new(AccessRequest)
+
+	if err := s.writeResource("POST", accessRequestResource, "/", input, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// AccessRequest gets the access request with id
+func (s Server) AccessRequest(id int) (*AccessRequest, error) {
+	request := new(AccessRequest)
+
+	if data, err := s.accessResource("GET", accessRequestResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, request); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", accessRequestResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// WaitForAccessApproval polls the access request with id every interval until
+// it is no longer pending or the context is done. It returns an
+// AccessRequestDeniedError if the request is resolved without being approved.
+func (s Server) WaitForAccessApproval(ctx context.Context, id int, interval time.Duration) (*AccessRequest, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		request, err := s.AccessRequest(id)
+
+		if err != nil {
+			return nil, err
+		}
+		switch request.Status {
+		case AccessRequestPending:
+		case AccessRequestApproved:
+			return request, nil
+		default:
+			return request, &AccessRequestDeniedError{Request: request}
+		}
+
+		select {
+		case <-ctx.Done():
+			return request, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PendingAccessRequests lists the access requests awaiting a response from
+// the user that the Server is acting as, paged according to paging
+func (s Server) PendingAccessRequests(paging Paging) ([]AccessRequest, error) {
+	var requests []AccessRequest
+
+	query := url.Values{
+		"filter.status":      {AccessRequestPending},
+		"filter.isMyRequest": {"false"},
+	}
+
+	if err := s.listRecords(accessRequestResource, "", query, paging, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ApproveAccessRequest approves the access request with id
+func (s Server) ApproveAccessRequest(id int, comment string) (*AccessRequest, error) {
+	return s.respondToAccessRequest(id, AccessRequestApproved, comment)
+}
+
+// DenyAccessRequest denies the access request with id
+func (s Server) DenyAccessRequest(id int, comment string) (*AccessRequest, error) {
+	return s.respondToAccessRequest(id, AccessRequestDenied, comment)
+}
+
+func (s Server) respondToAccessRequest(id int, status, comment string) (*AccessRequest, error) {
+	input := struct {
+		SecretAccessRequestID   int
+		Status, ResponseComment string
+	}{id, status, comment}
+
+	request := new(AccessRequest)
+
+	if err := s.writeResource("PUT", accessRequestResource, "/", input, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
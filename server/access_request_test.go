@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestApprovalRequired tests that Secret returns an ApprovalRequiredError when
+// the read is blocked pending approval
+func TestApprovalRequired(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"Access to this secret requires approval."}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	_, err = tss.Secret(1)
+	if approvalError, ok := err.(*ApprovalRequiredError); !ok {
+		t.Errorf("expected an ApprovalRequiredError, but found %T: %v", err, err)
+	} else {
+		validate("secret id", 1, approvalError.SecretID, t)
+	}
+}
+
+// TestRequestAccess tests that RequestAccess rejects a duration that is not
+// positive without sending the request
+func TestRequestAccess(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprint(w, `{"SecretAccessRequestID":7,"SecretID":1,"Status":"Pending"}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	for _, duration := range []time.Duration{0, -time.Hour} {
+		if _, err := tss.RequestAccess(1, AccessRequestOptions{Reason: "maintenance", Duration: duration}); err == nil {
+			t.Errorf("expected an error requesting access for %s", duration)
+		}
+	}
+	validate("requests", int32(0), atomic.LoadInt32(&requests), t)
+
+	request, err := tss.RequestAccess(1, AccessRequestOptions{Reason: "maintenance", Duration: time.Hour})
+	if err != nil {
+		t.Error("calling server.RequestAccess:", err)
+		return
+	}
+	validate("request ID", 7, request.SecretAccessRequestID, t)
+}
+
+// TestWaitForAccessApproval tests that WaitForAccessApproval polls a pending
+// request until it is approved or denied, and what the responses send
+func TestWaitForAccessApproval(t *testing.T) {
+	var polls int32
+	var status, response string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/secret-access-requests/7":
+			if atomic.AddInt32(&polls, 1) < 3 {
+				fmt.Fprint(w, `{"SecretAccessRequestID":7,"SecretID":1,"Status":"Pending"}`)
+			} else {
+				fmt.Fprintf(w, `{"SecretAccessRequestID":7,"SecretID":1,"Status":"%s","ResponseComment":"ok"}`, status)
+			}
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/secret-access-requests/":
+			body, _ := ioutil.ReadAll(r.Body)
+			response = string(body)
+			fmt.Fprint(w, `{"SecretAccessRequestID":7,"SecretID":1,"Status":"Approved"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status = AccessRequestApproved
+	request, err := tss.WaitForAccessApproval(ctx, 7, 10*time.Millisecond)
+	if err != nil {
+		t.Error("calling server.WaitForAccessApproval:", err)
+		return
+	}
+	validate("polls", int32(3), atomic.LoadInt32(&polls), t)
+	validate("status", AccessRequestApproved, request.Status, t)
+
+	atomic.StoreInt32(&polls, 0)
+	status = AccessRequestDenied
+	request, err = tss.WaitForAccessApproval(ctx, 7, 10*time.Millisecond)
+	if deniedError, ok := err.(*AccessRequestDeniedError); !ok {
+		t.Errorf("expected an AccessRequestDeniedError, but found %v", err)
+	} else {
+		validate("denied request", request, deniedError.Request, t)
+		validate("status", AccessRequestDenied, request.Status, t)
+	}
+
+	if _, err := tss.ApproveAccessRequest(7, "go ahead"); err != nil {
+		t.Error("calling server.ApproveAccessRequest:", err)
+	}
+	validate("approval", `{"SecretAccessRequestID":7,"Status":"Approved","ResponseComment":"go ahead"}`, response, t)
+
+	if _, err := tss.DenyAccessRequest(7, "not now"); err != nil {
+		t.Error("calling server.DenyAccessRequest:", err)
+	}
+	validate("denial", `{"SecretAccessRequestID":7,"Status":"Denied","ResponseComment":"not now"}`, response, t)
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauthExpirationResource is the HTTP URL path component for the resource
+// that revokes the current access token
+const oauthExpirationResource = "oauth-expiration"
+
+// expiryMargin is subtracted from the lifetime of a grant so that a cached
+// token is renewed shortly before the server would reject it
+const expiryMargin = 30 * time.Second
+
+// AccessGrant is an OAuth2 access grant issued by the Secret Server. The
+// Server sets Expires from ExpiresIn when it obtains the grant.
+type AccessGrant struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	Scope        string    `json:"scope"`
+	ExpiresIn    int       `json:"expires_in"`
+	Expires      time.Time `json:"-"`
+}
+
+// TokenInfo describes the access token the Server is using. Expires is zero
+// when the token's lifetime is unknown, e.g. for a StaticToken.
+type TokenInfo struct {
+	TokenType string
+	Scopes    []string
+	Expires   time.Time
+}
+
+// Authenticator obtains the access grant the API uses to authenticate to the
+// REST API. The tokenURL is the OAuth2 token endpoint of the Secret Server.
+type Authenticator interface {
+	Grant(tokenURL string) (*AccessGrant, error)
+}
+
+// TwoFactorRequiredError is returned when the Secret Server requires a
+// one-time password but neither a TOTPSecret nor an OTP callback is set
+type TwoFactorRequiredError struct {
+	Username string
+	Err      error
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return fmt.Sprintf("two-factor authentication is required for '%s' but no OTP is configured: %s", e.Username, e.Err)
+}
+
+func (e *TwoFactorRequiredError) Unwrap() error {
+	return e.Err
+}
+
+// PasswordGrant authenticates with the OAuth2 password grant, optionally
+// against an Active Directory domain. For accounts subject to two-factor
+// authentication, the one-time password is either generated from the
+// base32-encoded TOTPSecret or supplied by the OTP callback.
+type PasswordGrant struct {
+	Username, Password, Domain string
+	TOTPSecret                 string
+	OTP                        func() (string, error)
+}
+
+// Grant requests an access grant with the username and password
+func (p PasswordGrant) Grant(tokenURL string) (*AccessGrant, error) {
+	values := url.Values{
+		"username":   {p.Username},
+		"password":   {p.Password},
+		"grant_type": {"password"},
+	}
+	if p.Domain != "" {
+		values.Set("domain", p.Domain)
+	}
+
+	var header http.Header
+
+	if otp, err := p.oneTimePassword(); err != nil {
+		return nil, err
+	} else if otp != "" {
+		header = http.Header{"OTP": {otp}}
+	}
+
+	grant, err := requestGrant(tokenURL, values, header)
+
+	if header == nil && requiresTwoFactor(err) {
+		return nil, &TwoFactorRequiredError{Username: p.Username, Err: err}
+	}
+	return grant, err
+}
+
+// oneTimePassword returns the OTP to send with the grant request, if any
+func (p PasswordGrant) oneTimePassword() (string, error) {
+	switch {
+	case p.OTP != nil:
+		return p.OTP()
+	case p.TOTPSecret != "":
+		return TOTP(p.TOTPSecret, time.Now())
+	}
+	return "", nil
+}
+
+// requiresTwoFactor reports whether the error is the token endpoint rejecting
+// a grant request for lack of a one-time password
+func requiresTwoFactor(err error) bool {
+	responseError, ok := err.(*ResponseError)
+
+	if !ok || responseError.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	body := strings.ToLower(responseError.Body)
+
+	return strings.Contains(body, "otp") || strings.Contains(body, "two factor") ||
+		strings.Contains(body, "two-factor") || strings.Contains(body, "2fa")
+}
+
+// RefreshTokenGrant authenticates with the OAuth2 refresh_token grant only.
+// The refresh token is replaced by the one issued with each new grant.
+type RefreshTokenGrant struct {
+	RefreshToken string
+	mutex        sync.Mutex
+}
+
+// Grant exchanges the refresh token for an access grant
+func (r *RefreshTokenGrant) Grant(tokenURL string) (*AccessGrant, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	grant, err := requestGrant(tokenURL, url.Values{
+		"refresh_token": {r.RefreshToken},
+		"grant_type":    {"refresh_token"},
+	}, nil)
+
+	if err != nil {
+		return nil, err
+	}
+	if grant.RefreshToken != "" {
+		r.RefreshToken = grant.RefreshToken
+	}
+	return grant, nil
+}
+
+// StaticToken is a pre-issued bearer token that is used as-is
+type StaticToken string
+
+// Grant returns the static token without calling the token endpoint
+func (t StaticToken) Grant(string) (*AccessGrant, error) {
+	if t == "" {
+		return nil, fmt.Errorf("the static token is empty")
+	}
+	return &AccessGrant{AccessToken: string(t), TokenType: "bearer"}, nil
+}
+
+// TokenFunc is a callback that supplies the bearer token, e.g. from a broker.
+// It is called whenever the Server needs a token, so it should do its own
+// caching if that is expensive.
+type TokenFunc func() (string, error)
+
+// Grant calls the callback and returns the token it supplies
+func (f TokenFunc) Grant(string) (*AccessGrant, error) {
+	token, err := f()
+
+	if err != nil {
+		return nil, err
+	}
+	return &AccessGrant{AccessToken: token, TokenType: "bearer"}, nil
+}
+
+// requestGrant posts the form values to the token endpoint and parses the
+// resulting access grant
+func requestGrant(tokenURL string, values url.Values, header http.Header) (*AccessGrant, error) {
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(values.Encode()))
+
+	if err != nil {
+		log.Printf("[ERROR] creating req: POST %s: %s", tokenURL, err)
+		return nil, err
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	data, _, err := handleResponse((&http.Client{}).Do(req))
+
+	if err != nil {
+		log.Print("[ERROR] grant response error:", err)
+		return nil, err
+	}
+
+	grant := new(AccessGrant)
+
+	if err = json.Unmarshal(data, grant); err != nil {
+		log.Print("[ERROR] parsing grant response:", err)
+		return nil, err
+	}
+	return grant, nil
+}
+
+// cachedGrant is an access grant together with the time it expires
+type cachedGrant struct {
+	*AccessGrant
+	expires time.Time
+}
+
+// valid reports whether the grant can still be used
+func (c cachedGrant) valid() bool {
+	return c.AccessGrant != nil && time.Now().Before(c.expires)
+}
+
+// grantCache holds the access grants obtained by a Server, keyed by the token
+// endpoint they were issued by. It is shared by all copies of the Server.
+type grantCache struct {
+	mutex  sync.Mutex
+	grants map[string]cachedGrant
+}
+
+// get returns the cached grant for the tokenURL, if it is still valid
+func (c *grantCache) get(tokenURL string) (*AccessGrant, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if grant, ok := c.grants[tokenURL]; ok && grant.valid() {
+		return grant.AccessGrant, true
+	}
+	return nil, false
+}
+
+// put caches the grant for the tokenURL until shortly before it expires.
+// Grants without a lifetime are not cached.
+func (c *grantCache) put(tokenURL string, grant *AccessGrant) {
+	if c == nil || grant.Expires.IsZero() {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.grants == nil {
+		c.grants = make(map[string]cachedGrant)
+	}
+	c.grants[tokenURL] = cachedGrant{
+		AccessGrant: grant,
+		expires:     grant.Expires.Add(-expiryMargin),
+	}
+}
+
+// remove drops the cached grant for the tokenURL
+func (c *grantCache) remove(tokenURL string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.grants, tokenURL)
+}
+
+// Logout revokes the cached access tokens, if there are any, and clears the
+// cached grants so that the next request authenticates afresh
+func (s Server) Logout() error {
+	var err error
+
+	for _, baseURL := range s.allBaseURLs() {
+		tokenURL := s.endpointURLFor(baseURL, "token", "")
+
+		if _, ok := s.grants.get(tokenURL); !ok {
+			continue
+		}
+		if _, e := s.accessEndpoint(context.Background(), baseURL, "POST", oauthExpirationResource, "", nil); e != nil {
+			log.Print("[ERROR] revoking the access token:", e)
+			err = e
+		}
+		s.grants.remove(tokenURL)
+	}
+	return err
+}
+
+// Close logs out of the Secret Server and stops probing unhealthy endpoints;
+// see Logout
+func (s Server) Close() error {
+	if s.endpoints != nil {
+		s.endpoints.close()
+	}
+	return s.Logout()
+}
+
+// LogoutWhenDone logs out of the Secret Server once the context is done,
+// scoping the access token to the lifetime of the context
+func (s Server) LogoutWhenDone(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.Logout()
+	}()
+}
+
+// TokenInfo returns the type, scopes and expiry of the access token the
+// Server is using on its preferred endpoint, authenticating if need be
+func (s Server) TokenInfo() (*TokenInfo, error) {
+	grant, err := s.getGrant(s.preferredBaseURL())
+
+	if err != nil {
+		return nil, err
+	}
+	return &TokenInfo{
+		TokenType: grant.TokenType,
+		Scopes:    strings.Fields(grant.Scope),
+		Expires:   grant.Expires,
+	}, nil
+}
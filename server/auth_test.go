@@ -0,0 +1,281 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPasswordGrant tests that the password grant sends the domain and that
+// the resulting access grant is cached
+func TestPasswordGrant(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			t.Error("parsing the token request:", err)
+		}
+		if !validate("grant type", "password", r.PostForm.Get("grant_type"), t) ||
+			!validate("domain", "example", r.PostForm.Get("domain"), t) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","expires_in":1200}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: PasswordGrant{Username: "user", Password: "pass", Domain: "example"},
+		ServerURL:     ts.URL,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := tss.getAccessToken(ts.URL)
+		if err != nil {
+			t.Error("calling getAccessToken:", err)
+			return
+		}
+		validate("access token", "t0k3n", token, t)
+	}
+	validate("token requests", 1, requests, t)
+}
+
+// TestCredentialsMapToPasswordGrant tests that New maps the Credentials onto
+// the password grant when no Authenticator is set
+func TestCredentialsMapToPasswordGrant(t *testing.T) {
+	tss, err := New(Configuration{
+		Credentials: UserCredential{Username: "user", Password: "pass"},
+		ServerURL:   "https://example.local/SecretServer",
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	if grant, ok := tss.Authenticator.(PasswordGrant); !ok {
+		t.Errorf("expected a PasswordGrant, but found %T", tss.Authenticator)
+	} else {
+		validate("username", "user", grant.Username, t)
+		validate("password", "pass", grant.Password, t)
+	}
+}
+
+// TestStaticToken tests that a StaticToken is used without calling the token
+// endpoint
+func TestStaticToken(t *testing.T) {
+	tss, err := New(Configuration{
+		Authenticator: StaticToken("st4t1c"),
+		ServerURL:     "https://example.invalid/SecretServer",
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	token, err := tss.getAccessToken(tss.ServerURL)
+	if err != nil {
+		t.Error("calling getAccessToken:", err)
+		return
+	}
+	validate("access token", "st4t1c", token, t)
+}
+
+// TestPasswordGrantOTP tests that the OTP header is sent when a callback is
+// set, and that a TwoFactorRequiredError is returned when it is not
+func TestPasswordGrantOTP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("OTP") != "123456" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"invalid_grant","error_description":"An OTP is required"}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","expires_in":1200}`)
+	}))
+	defer ts.Close()
+
+	grant := PasswordGrant{Username: "user", Password: "pass"}
+	if _, err := grant.Grant(ts.URL); err == nil {
+		t.Error("expected an error without an OTP")
+	} else if _, ok := err.(*TwoFactorRequiredError); !ok {
+		t.Errorf("expected a TwoFactorRequiredError, but found %T: %s", err, err)
+	}
+
+	grant.OTP = func() (string, error) { return "123456", nil }
+	if g, err := grant.Grant(ts.URL); err != nil {
+		t.Error("calling Grant with an OTP:", err)
+	} else {
+		validate("access token", "t0k3n", g.AccessToken, t)
+	}
+}
+
+// TestLogout tests that Logout revokes the cached token and clears the grant
+func TestLogout(t *testing.T) {
+	grants, revoked := 0, ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			grants++
+			fmt.Fprintf(w, `{"access_token":"t0k3n%d","token_type":"bearer","expires_in":1200}`, grants)
+		case "/api/v1/oauth-expiration/":
+			revoked = r.Header.Get("Authorization")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: PasswordGrant{Username: "user", Password: "pass"},
+		ServerURL:     ts.URL,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	if err := tss.Logout(); err != nil || revoked != "" {
+		t.Error("expected Logout without a cached grant to do nothing:", err)
+		return
+	}
+	if _, err := tss.getAccessToken(ts.URL); err != nil {
+		t.Error("calling getAccessToken:", err)
+		return
+	}
+	if err := tss.Logout(); err != nil {
+		t.Error("calling Logout:", err)
+		return
+	}
+	validate("revoked token", "Bearer t0k3n1", revoked, t)
+
+	if token, err := tss.getAccessToken(ts.URL); err != nil {
+		t.Error("calling getAccessToken:", err)
+	} else {
+		validate("access token after Logout", "t0k3n2", token, t)
+	}
+}
+
+// TestLogoutRotation tests that Logout does not change which endpoint the
+// next request will use
+func TestLogoutRotation(t *testing.T) {
+	tss, err := New(Configuration{
+		Authenticator:  StaticToken("t0k3n"),
+		ServerURLs:     []string{"https://a.example.invalid", "https://b.example.invalid"},
+		FailoverPolicy: RoundRobinFailover,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	if err := tss.Logout(); err != nil {
+		t.Error("calling Logout:", err)
+	}
+	validate("next endpoint", "https://a.example.invalid", tss.preferredBaseURL(), t)
+}
+
+// TestTokenInfo tests that TokenInfo reports the scopes and expiry of the grant
+func TestTokenInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","scope":"read write","expires_in":1200}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: PasswordGrant{Username: "user", Password: "pass"},
+		ServerURL:     ts.URL,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	info, err := tss.TokenInfo()
+	if err != nil {
+		t.Error("calling server.TokenInfo:", err)
+		return
+	}
+	validate("scope count", 2, len(info.Scopes), t)
+	if remaining := time.Until(info.Expires); remaining < 19*time.Minute || remaining > 20*time.Minute {
+		t.Errorf("expected the token to expire in 20 minutes, but it expires at %s", info.Expires)
+	}
+}
+
+// TestTokenInfoRotation tests that TokenInfo reports the grant of the endpoint
+// that the next request will use without changing which endpoint that is
+func TestTokenInfoRotation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","scope":"read","expires_in":1200}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator:  PasswordGrant{Username: "user", Password: "pass"},
+		ServerURLs:     []string{ts.URL, "https://example.invalid/SecretServer"},
+		FailoverPolicy: RoundRobinFailover,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := tss.TokenInfo(); err != nil {
+			t.Error("calling server.TokenInfo:", err)
+			return
+		}
+	}
+	validate("next endpoint", ts.URL, tss.preferredBaseURL(), t)
+}
+
+// TestRevokedToken tests that a cached access token that the server rejects is
+// dropped, and that the request is retried once with a new one
+func TestRevokedToken(t *testing.T) {
+	var grants, calls int
+	accepted := "t0k3n-2"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			grants++
+			fmt.Fprintf(w, `{"access_token":"t0k3n-%d","token_type":"bearer","expires_in":1200}`, grants)
+		case "/api/v1/secrets/1":
+			calls++
+			if r.Header.Get("Authorization") != "Bearer "+accepted {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"ID":1,"Name":"Revoked"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: PasswordGrant{Username: "user", Password: "pass"},
+		ServerURL:     ts.URL,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	s, err := tss.Secret(1)
+	if err != nil {
+		t.Error("calling server.Secret:", err)
+		return
+	}
+	validate("secret name", "Revoked", s.Name, t)
+	validate("grants after the token was rejected", 2, grants, t)
+	validate("calls after the token was rejected", 2, calls, t)
+
+	accepted = "none"
+	if _, err = tss.Secret(1); err == nil {
+		t.Error("expected an error when every token is rejected")
+	}
+	validate("grants after every token was rejected", 3, grants, t)
+	validate("calls after every token was rejected", 4, calls, t)
+}
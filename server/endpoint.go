@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// healthCheckResource is the HTTP URL path component for the unauthenticated
+// health check resource
+const healthCheckResource = "healthcheck"
+
+// FailoverPolicy determines the order in which the endpoints are tried
+type FailoverPolicy int
+
+const (
+	// PriorityFailover always prefers the first healthy endpoint in the order
+	// in which they are configured
+	PriorityFailover FailoverPolicy = iota
+	// RoundRobinFailover spreads requests across the healthy endpoints
+	RoundRobinFailover
+)
+
+// endpointPool tracks the health of the endpoints of a Server. It is shared by
+// all copies of the Server. The unhealthy endpoints are only probed while
+// there are any, until the pool is closed.
+type endpointPool struct {
+	mutex     sync.Mutex
+	baseURLs  []string
+	unhealthy map[string]bool
+	policy    FailoverPolicy
+	next      int
+	probing   bool
+	closed    bool
+	stop      chan struct{}
+}
+
+func newEndpointPool(baseURLs []string, policy FailoverPolicy) *endpointPool {
+	return &endpointPool{
+		baseURLs:  baseURLs,
+		unhealthy: make(map[string]bool),
+		policy:    policy,
+		stop:      make(chan struct{}),
+	}
+}
+
+// candidates returns the base URLs in the order in which they should be tried:
+// the healthy endpoints according to the policy, then the unhealthy ones as a
+// last resort
+func (p *endpointPool) candidates() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	candidates := p.ordered()
+
+	if p.policy == RoundRobinFailover {
+		p.next++
+	}
+	return candidates
+}
+
+// preferred returns the base URL of the endpoint that the next request would
+// try first, without taking its turn in the rotation
+func (p *endpointPool) preferred() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.ordered()[0]
+}
+
+// all returns the base URLs of all of the endpoints in the order in which they
+// are configured
+func (p *endpointPool) all() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return append([]string(nil), p.baseURLs...)
+}
+
+// ordered returns the base URLs in the order in which they should be tried
+// now. The caller must hold the mutex.
+func (p *endpointPool) ordered() []string {
+	start := 0
+
+	if p.policy == RoundRobinFailover && len(p.baseURLs) > 0 {
+		start = p.next % len(p.baseURLs)
+	}
+
+	var healthy, unhealthy []string
+
+	for i := range p.baseURLs {
+		baseURL := p.baseURLs[(start+i)%len(p.baseURLs)]
+
+		if p.unhealthy[baseURL] {
+			unhealthy = append(unhealthy, baseURL)
+		} else {
+			healthy = append(healthy, baseURL)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// mark records whether the endpoint with the given base URL is healthy. It
+// reports whether the unhealthy endpoints should start being probed, which
+// they should when one of several endpoints becomes unhealthy and they are
+// not already being probed.
+func (p *endpointPool) mark(baseURL string, healthy bool) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.unhealthy[baseURL] == !healthy {
+		return false
+	}
+	if healthy {
+		log.Printf("[INFO] endpoint %s has recovered", baseURL)
+		delete(p.unhealthy, baseURL)
+		return false
+	}
+	log.Printf("[WARN] marking endpoint %s as unhealthy", baseURL)
+	p.unhealthy[baseURL] = true
+
+	if p.probing || p.closed || len(p.baseURLs) < 2 {
+		return false
+	}
+	p.probing = true
+	return true
+}
+
+// unhealthyURLs returns the base URLs of the endpoints marked as unhealthy
+func (p *endpointPool) unhealthyURLs() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var baseURLs []string
+
+	for _, baseURL := range p.baseURLs {
+		if p.unhealthy[baseURL] {
+			baseURLs = append(baseURLs, baseURL)
+		}
+	}
+	return baseURLs
+}
+
+// doneProbing reports whether the endpoints no longer need to be probed,
+// because they are all healthy or the pool is closed, and if so records that
+// probing has stopped
+func (p *endpointPool) doneProbing() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.unhealthy) > 0 && !p.closed {
+		return false
+	}
+	p.probing = false
+	return true
+}
+
+// close stops probing the endpoints, now and in the future
+func (p *endpointPool) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+	}
+}
+
+// isEndpointFailure reports whether the error means that the endpoint could
+// not be reached or failed to handle the request, as opposed to rejecting it
+func isEndpointFailure(err error) bool {
+	switch e := err.(type) {
+	case *url.Error:
+		return true
+	case *ResponseError:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// isNotSent reports whether the error means that the request was never sent,
+// because the connection to the endpoint could not be made
+func isNotSent(err error) bool {
+	var opError *net.OpError
+
+	return errors.As(err, &opError) && opError.Op == "dial"
+}
+
+// canFailover reports whether a request with the method that failed on one
+// endpoint with the error may be retried on the next one. Requests that may
+// have been applied are only retried if they are idempotent, so that a 5xx
+// from a POST, PUT or PATCH is not repeated on another endpoint.
+func canFailover(method string, err error) bool {
+	switch method {
+	case "GET", "DELETE":
+		return true
+	}
+	return isNotSent(err)
+}
+
+// withFailover calls request with the base URL of each endpoint in turn until
+// one of them does not fail, or the failure means that the request with the
+// method must not be retried, marking the endpoints healthy or unhealthy
+// accordingly. A failure because the context is done says nothing about the
+// endpoint, so it is neither marked nor retried.
+func (s Server) withFailover(ctx context.Context, method string, request func(baseURL string) ([]byte, error)) ([]byte, error) {
+	var data []byte
+	var err error
+
+	for _, baseURL := range s.baseURLs() {
+		data, err = request(baseURL)
+
+		if s.endpoints == nil || err != nil && ctx.Err() != nil {
+			break
+		}
+		if !isEndpointFailure(err) {
+			s.markEndpoint(baseURL, true)
+			break
+		}
+		s.markEndpoint(baseURL, false)
+		log.Printf("[WARN] endpoint %s failed: %s", baseURL, err)
+
+		if !canFailover(method, err) {
+			break
+		}
+	}
+	return data, err
+}
+
+// markEndpoint records whether the endpoint with the given base URL is
+// healthy, and starts probing the unhealthy endpoints if need be
+func (s Server) markEndpoint(baseURL string, healthy bool) {
+	if s.endpoints != nil && s.endpoints.mark(baseURL, healthy) {
+		go s.probeEndpoints()
+	}
+}
+
+// probeEndpoints periodically checks whether the unhealthy endpoints have
+// recovered, until they all have or the Server is closed
+func (s Server) probeEndpoints() {
+	ticker := time.NewTicker(s.RecoveryInterval)
+	defer ticker.Stop()
+
+	for !s.endpoints.doneProbing() {
+		select {
+		case <-s.endpoints.stop:
+		case <-ticker.C:
+			for _, baseURL := range s.endpoints.unhealthyURLs() {
+				s.endpoints.mark(baseURL, s.probe(baseURL))
+			}
+		}
+	}
+}
+
+// probe reports whether the endpoint with the given base URL is up, which it
+// is if its unauthenticated health check responds without a 5xx status
+func (s Server) probe(baseURL string) bool {
+	client := &http.Client{Timeout: s.RecoveryInterval}
+	_, _, err := handleResponse(client.Get(s.endpointURLFor(baseURL, healthCheckResource, "")))
+
+	return !isEndpointFailure(err)
+}
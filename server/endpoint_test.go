@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFailover tests that requests fail over to the next endpoint when one
+// responds with a 5xx status, and that the failed endpoint is marked unhealthy
+func TestFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","expires_in":1200}`)
+		case "/api/v1/secrets/1":
+			fmt.Fprint(w, `{"ID":1,"Name":"Failover"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer up.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: PasswordGrant{Username: "user", Password: "pass"},
+		ServerURLs:    []string{down.URL, up.URL},
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	defer tss.Close()
+
+	s, err := tss.Secret(1)
+	if err != nil {
+		t.Error("calling server.Secret:", err)
+		return
+	}
+	validate("secret name", "Failover", s.Name, t)
+
+	candidates := tss.baseURLs()
+	validate("preferred endpoint", up.URL, candidates[0], t)
+	validate("last resort endpoint", down.URL, candidates[1], t)
+}
+
+// TestFailoverIdempotence tests that a request that may have been applied is
+// only retried on the next endpoint if it is idempotent, and that any request
+// is retried if it could not be sent
+func TestFailoverIdempotence(t *testing.T) {
+	var succeeded int32
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	working := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&succeeded, 1)
+		fmt.Fprint(w, `{"ID":1,"Name":"Failover"}`)
+	}))
+	defer working.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	for _, test := range []struct {
+		method  string
+		baseURL string
+		retried bool
+		label   string
+	}{
+		{"GET", failing.URL, true, "GET after a 5xx"},
+		{"DELETE", failing.URL, true, "DELETE after a 5xx"},
+		{"POST", failing.URL, false, "POST after a 5xx"},
+		{"PUT", failing.URL, false, "PUT after a 5xx"},
+		{"POST", closed.URL, true, "POST that could not be sent"},
+	} {
+		tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURLs: []string{test.baseURL, working.URL}})
+		if err != nil {
+			t.Error("configuring the Server:", err)
+			return
+		}
+		atomic.StoreInt32(&succeeded, 0)
+
+		_, err = tss.accessResource(test.method, resource, "1", nil)
+		tss.Close()
+
+		validate(test.label+" retried", test.retried, atomic.LoadInt32(&succeeded) == 1, t)
+		validate(test.label+" error", test.retried, err == nil, t)
+	}
+}
+
+// TestRoundRobin tests that the round-robin policy rotates the endpoints
+func TestRoundRobin(t *testing.T) {
+	pool := newEndpointPool([]string{"a", "b", "c"}, RoundRobinFailover)
+
+	validate("first candidate", "a", pool.candidates()[0], t)
+	validate("second candidate", "b", pool.candidates()[0], t)
+
+	pool.mark("c", false)
+	candidates := pool.candidates()
+	validate("third candidate", "a", candidates[0], t)
+	validate("unhealthy candidate", "c", candidates[2], t)
+}
+
+// TestProbing tests that unhealthy endpoints are only probed until they have
+// recovered
+func TestProbing(t *testing.T) {
+	var down int32 = 1
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer flaky.Close()
+
+	tss, err := New(Configuration{
+		Authenticator:    StaticToken("t0k3n"),
+		ServerURLs:       []string{flaky.URL, "https://example.invalid"},
+		RecoveryInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	probing := func() bool {
+		tss.endpoints.mutex.Lock()
+		defer tss.endpoints.mutex.Unlock()
+		return tss.endpoints.probing
+	}
+	validate("probing before a failure", false, probing(), t)
+
+	tss.markEndpoint(flaky.URL, false)
+	validate("probing after a failure", true, probing(), t)
+
+	atomic.StoreInt32(&down, 0)
+	for deadline := time.Now().Add(time.Second); probing() && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	validate("probing after recovery", false, probing(), t)
+	validate("unhealthy endpoints", 0, len(tss.endpoints.unhealthyURLs()), t)
+}
+
+// TestFailoverCanceled tests that a request that fails because its context is
+// done leaves the health of the endpoint as it was
+func TestFailoverCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{
+		Authenticator: StaticToken("t0k3n"),
+		ServerURLs:    []string{ts.URL, "https://example.invalid"},
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	defer tss.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	_, err = tss.withFailover(ctx, "GET", func(baseURL string) ([]byte, error) {
+		attempts++
+		return tss.accessEndpoint(ctx, baseURL, "GET", resource, "1", nil)
+	})
+	if err == nil {
+		t.Error("expected the request to time out")
+	}
+	validate("attempts", 1, attempts, t)
+	validate("unhealthy endpoints", 0, len(tss.endpoints.unhealthyURLs()), t)
+}
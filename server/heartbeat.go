@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Heartbeat statuses of interest; Secret Server reports many kinds of failure
+const (
+	HeartbeatPending    = "Pending"
+	HeartbeatProcessing = "Processing"
+	HeartbeatSuccess    = "Success"
+)
+
+// HeartbeatStatus is the outcome of the last heartbeat of a secret, which
+// checks that its credentials still work on the target system
+type HeartbeatStatus struct {
+	ID                 int
+	Status, Message    string
+	LastHeartbeatCheck Time
+}
+
+// InProgress reports whether the heartbeat has yet to finish
+func (h HeartbeatStatus) InProgress() bool {
+	return h.Status == HeartbeatPending || h.Status == HeartbeatProcessing
+}
+
+// Succeeded reports whether the heartbeat found the credentials to be valid
+func (h HeartbeatStatus) Succeeded() bool {
+	return h.Status == HeartbeatSuccess
+}
+
+// Heartbeat triggers a heartbeat of the secret with id. The heartbeat happens
+// asynchronously; see HeartbeatStatus and WaitForHeartbeat.
+func (s Server) Heartbeat(id int) error {
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/heartbeat", id), nil)
+	return err
+}
+
+// HeartbeatStatus gets the status of the last heartbeat of the secret with id
+func (s Server) HeartbeatStatus(id int) (*HeartbeatStatus, error) {
+	status := new(HeartbeatStatus)
+	path := fmt.Sprintf("%d/heartbeat-status", id)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, status); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	status.ID = id
+	return status, nil
+}
+
+// WaitForHeartbeat polls the heartbeat status of the secret with id every
+// interval until the heartbeat is no longer in progress or the context is
+// done, and returns the final status. LastCheck is the LastHeartbeatCheck of
+// the status from before the heartbeat was triggered: a status that is not in
+// progress is only taken to be that of this heartbeat once the heartbeat has
+// been seen in progress or, should it have finished before the first poll,
+// once the LastHeartbeatCheck differs from lastCheck. The timestamps are only
+// compared with each other, so the clocks of the Server and the Secret Server
+// need not agree.
+func (s Server) WaitForHeartbeat(ctx context.Context, id int, lastCheck Time, interval time.Duration) (*HeartbeatStatus, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seenInProgress := false
+
+	for {
+		status, err := s.HeartbeatStatus(id)
+
+		if err != nil {
+			return status, err
+		}
+		if status.InProgress() {
+			seenInProgress = true
+		} else if seenInProgress || !status.LastHeartbeatCheck.Equal(lastCheck.Time) {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWaitForHeartbeat tests that WaitForHeartbeat skips the status of a
+// previous heartbeat, polls through Pending and Processing to the final
+// status, and stops when the context is done
+func TestWaitForHeartbeat(t *testing.T) {
+	var polls, heartbeats int32
+	statuses := []string{
+		`{"Status":"Success","LastHeartbeatCheck":"2021-01-01T10:00:00"}`,
+		`{"Status":"Pending","LastHeartbeatCheck":"2021-01-01T10:00:00"}`,
+		`{"Status":"Processing","LastHeartbeatCheck":"2021-01-01T10:00:00"}`,
+		`{"Status":"Failed","Message":"Access denied","LastHeartbeatCheck":"2021-01-01T10:00:00"}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/v1/secrets/1/heartbeat":
+			atomic.AddInt32(&heartbeats, 1)
+		case r.Method == "GET" && r.URL.Path == "/api/v1/secrets/1/heartbeat-status":
+			poll := 0
+			if atomic.LoadInt32(&heartbeats) > 0 {
+				poll = int(atomic.AddInt32(&polls, 1)) - 1
+			}
+			if poll >= len(statuses) {
+				poll = len(statuses) - 1
+			}
+			fmt.Fprint(w, statuses[poll])
+		case r.Method == "GET" && r.URL.Path == "/api/v1/secrets/2/heartbeat-status":
+			fmt.Fprint(w, `{"Status":"Pending"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	before, err := tss.HeartbeatStatus(1)
+	if err != nil {
+		t.Error("calling server.HeartbeatStatus:", err)
+		return
+	}
+	if err := tss.Heartbeat(1); err != nil {
+		t.Error("calling server.Heartbeat:", err)
+		return
+	}
+	validate("heartbeats", int32(1), atomic.LoadInt32(&heartbeats), t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := tss.WaitForHeartbeat(ctx, 1, before.LastHeartbeatCheck, 10*time.Millisecond)
+	if err != nil {
+		t.Error("calling server.WaitForHeartbeat:", err)
+		return
+	}
+	validate("polls", int32(4), atomic.LoadInt32(&polls), t)
+	validate("status", "Failed", status.Status, t)
+	validate("message", "Access denied", status.Message, t)
+	validate("ID", 1, status.ID, t)
+	if status.InProgress() || status.Succeeded() {
+		t.Error("expected the heartbeat to have failed")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	status, err = tss.WaitForHeartbeat(ctx, 2, Time{}, 10*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, but found %v", err)
+	} else if !status.InProgress() {
+		t.Error("expected the last status to be in progress")
+	}
+}
+
+// TestWaitForHeartbeatFinished tests that WaitForHeartbeat returns a status
+// whose LastHeartbeatCheck changed without waiting for it to be seen in
+// progress, whatever the time of the check says
+func TestWaitForHeartbeatFinished(t *testing.T) {
+	var heartbeats int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			atomic.AddInt32(&heartbeats, 1)
+		default:
+			// a check in the local time of a Secret Server whose clock is behind
+			check := "2000-01-01T10:00:00"
+			if atomic.LoadInt32(&heartbeats) > 0 {
+				check = "2000-01-01T10:05:00"
+			}
+			fmt.Fprintf(w, `{"Status":"Success","LastHeartbeatCheck":"%s"}`, check)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	before, err := tss.HeartbeatStatus(1)
+	if err != nil {
+		t.Error("calling server.HeartbeatStatus:", err)
+		return
+	}
+	if err := tss.Heartbeat(1); err != nil {
+		t.Error("calling server.Heartbeat:", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := tss.WaitForHeartbeat(ctx, 1, before.LastHeartbeatCheck, 10*time.Millisecond)
+	if err != nil {
+		t.Error("calling server.WaitForHeartbeat:", err)
+	} else if !status.Succeeded() {
+		t.Errorf("expected the heartbeat to have succeeded, but found %s", status.Status)
+	}
+}
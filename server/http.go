@@ -6,11 +6,23 @@ import (
 	"net/http"
 )
 
+// ResponseError is returned when the Secret Server responds with a status
+// other than 2xx. The Body is truncated to 256 bytes.
+type ResponseError struct {
+	StatusCode   int
+	Status, Body string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Status, e.Body)
+}
+
 // handleResponse processes the response according to the HTTP status
 func handleResponse(res *http.Response, err error) ([]byte, *http.Response, error) {
 	if err != nil { // fall-through if there was an underlying err
 		return nil, res, err
 	}
+	defer res.Body.Close()
 
 	data, err := ioutil.ReadAll(res.Body)
 
@@ -28,5 +40,5 @@ func handleResponse(res *http.Response, err error) ([]byte, *http.Response, erro
 		data = append(data[:256], []byte("...")...)
 	}
 
-	return nil, res, fmt.Errorf("%s: %s", res.Status, string(data))
+	return nil, res, &ResponseError{StatusCode: res.StatusCode, Status: res.Status, Body: string(data)}
 }
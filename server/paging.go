@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// defaultPageSize is the number of records requested per page
+const defaultPageSize = 100
+
+// Paging limits a list to at most Take records, starting at the record with
+// the index Skip. A Take of zero lists all of the remaining records.
+type Paging struct {
+	Skip, Take int
+}
+
+// page is a page of records returned by a list endpoint
+type page struct {
+	Records           []json.RawMessage
+	Skip, Take, Total int
+	HasNext           bool
+}
+
+// listResource gets the records of the resource at path, page by page,
+// passing each page of records to handle until there are no more pages or
+// the paging limit is reached.
+func (s Server) listResource(resource, path string, query url.Values, paging Paging, handle func(records json.RawMessage) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	remaining := paging.Take
+
+	for skip := paging.Skip; ; {
+		take := defaultPageSize
+
+		if paging.Take > 0 && remaining < take {
+			take = remaining
+		}
+		query.Set("skip", strconv.Itoa(skip))
+		query.Set("take", strconv.Itoa(take))
+
+		data, err := s.accessResource("GET", resource, path+"?"+query.Encode(), nil)
+
+		if err != nil {
+			return err
+		}
+
+		records := new(page)
+
+		if err = json.Unmarshal(data, records); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return err
+		}
+		if paging.Take > 0 && len(records.Records) > remaining {
+			records.Records = records.Records[:remaining]
+		}
+
+		raw, err := json.Marshal(records.Records)
+
+		if err != nil {
+			return err
+		}
+		if err = handle(raw); err != nil {
+			return err
+		}
+
+		remaining -= len(records.Records)
+
+		if !records.HasNext || len(records.Records) == 0 || paging.Take > 0 && remaining <= 0 {
+			return nil
+		}
+		skip += len(records.Records)
+	}
+}
+
+// listRecords gets the records of the resource at path, as listResource does,
+// and appends them to the slice that records points to.
+func (s Server) listRecords(resource, path string, query url.Values, paging Paging, records interface{}) error {
+	all := reflect.ValueOf(records).Elem()
+
+	return s.listResource(resource, path, query, paging, func(data json.RawMessage) error {
+		page := reflect.New(all.Type())
+
+		if err := json.Unmarshal(data, page.Interface()); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return err
+		}
+		all.Set(reflect.AppendSlice(all, page.Elem()))
+		return nil
+	})
+}
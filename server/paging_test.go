@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// TestPaging tests that lists are fetched page by page and limited by Paging
+func TestPaging(t *testing.T) {
+	const total = 250
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+
+		var records []SecretAudit
+		for i := skip; i < skip+take && i < total; i++ {
+			records = append(records, SecretAudit{SecretAuditID: i})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Records": records, "Skip": skip, "Take": take, "Total": total, "HasNext": skip+take < total,
+		})
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	audits, err := tss.Audits(AuditFilter{})
+	if err != nil {
+		t.Error("calling server.Audits:", err)
+		return
+	}
+	validate("audit count", total, len(audits), t)
+
+	audits, err = tss.SecretAudits(1, AuditFilter{Paging: Paging{Skip: 90, Take: 120}})
+	if err != nil {
+		t.Error("calling server.SecretAudits:", err)
+		return
+	}
+	validate("paged audit count", 120, len(audits), t)
+	validate("first paged audit", 90, audits[0].SecretAuditID, t)
+	validate("last paged audit", 209, audits[len(audits)-1].SecretAuditID, t)
+}
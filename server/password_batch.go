@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// defaultConcurrency is the number of passwords generated at once when no
+// concurrency is given
+const defaultConcurrency = 4
+
+// PasswordRequest asks for Count passwords for the field identified by the
+// Slug on the secret template with the TemplateID
+type PasswordRequest struct {
+	TemplateID int
+	Slug       string
+	Count      int
+}
+
+// PasswordResult holds the passwords generated for a PasswordRequest, or the
+// error that prevented them from being generated
+type PasswordResult struct {
+	Request   PasswordRequest
+	Passwords []string
+	Err       error
+}
+
+// GeneratePasswords generates the passwords for each of the requests, making
+// at most concurrency calls to the Secret Server at once. The results are in
+// the same order as the requests; an unknown template or slug, or a negative
+// Count, is reported as the error of the result rather than being requested.
+func (s Server) GeneratePasswords(requests []PasswordRequest, concurrency int) []PasswordResult {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	results := make([]PasswordResult, len(requests))
+	semaphore := make(chan struct{}, concurrency)
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+
+	for i, request := range requests {
+		results[i] = PasswordResult{Request: request}
+
+		if request.Count < 0 {
+			results[i].Err = fmt.Errorf("cannot generate %d passwords", request.Count)
+			continue
+		}
+		results[i].Passwords = make([]string, request.Count)
+
+		template, err := s.SecretTemplate(request.TemplateID)
+
+		if err == nil {
+			if _, found := template.GetField(request.Slug); !found {
+				err = errUnknownSlug(request.Slug, template)
+			}
+		}
+		if err != nil {
+			results[i].Passwords = nil
+			results[i].Err = err
+			continue
+		}
+
+		for j := 0; j < request.Count; j++ {
+			wait.Add(1)
+			semaphore <- struct{}{}
+
+			go func(result *PasswordResult, j int, template *SecretTemplate) {
+				defer func() {
+					<-semaphore
+					wait.Done()
+				}()
+
+				password, err := s.GeneratePassword(result.Request.Slug, template)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if err != nil {
+					if result.Err == nil {
+						result.Err = err
+					}
+					return
+				}
+				result.Passwords[j] = password
+			}(&results[i], j, template)
+		}
+	}
+	wait.Wait()
+
+	// don't return partial batches alongside an error
+	for i := range results {
+		if results[i].Err != nil {
+			results[i].Passwords = nil
+		}
+	}
+	return results
+}
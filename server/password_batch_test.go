@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestGeneratePasswords tests that GeneratePasswords returns a result per
+// request and reports unknown slugs and negative counts as errors without
+// requesting them
+func TestGeneratePasswords(t *testing.T) {
+	var generated, unknown int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/secret-templates/6":
+			fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[
+				{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+		case "/api/v1/secret-templates/generate-password/61":
+			fmt.Fprintf(w, `"p@ss\"%d"`, atomic.AddInt32(&generated, 1))
+		default:
+			atomic.AddInt32(&unknown, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	results := tss.GeneratePasswords([]PasswordRequest{
+		{TemplateID: 6, Slug: "password", Count: 10},
+		{TemplateID: 6, Slug: "nonexistent", Count: 1},
+		{TemplateID: 7, Slug: "password", Count: -1},
+	}, 3)
+
+	validate("result count", 3, len(results), t)
+	if results[0].Err != nil {
+		t.Error("generating the passwords:", results[0].Err)
+	} else {
+		validate("password count", 10, len(results[0].Passwords), t)
+		for _, password := range results[0].Passwords {
+			if len(password) < len(`p@ss"1`) {
+				t.Errorf("unexpected password '%s'", password)
+			}
+		}
+	}
+	if results[1].Err == nil {
+		t.Error("expected an error for the unknown slug")
+	}
+	if results[2].Err == nil {
+		t.Error("expected an error for the negative count")
+	}
+	validate("generated passwords", int32(10), atomic.LoadInt32(&generated), t)
+	validate("unknown requests", int32(0), atomic.LoadInt32(&unknown), t)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// defaultPollInterval is how often the Wait helpers poll when no interval is given
+const defaultPollInterval = 5 * time.Second
+
+// ChangePasswordOptions says how to change the password of a secret on the
+// target system. When NewPassword is empty, the password is randomized
+// according to the field's password requirement.
+type ChangePasswordOptions struct {
+	NewPassword string `json:",omitempty"`
+	Comment     string `json:",omitempty"`
+}
+
+// PasswordChangeStatus is the state of the remote password change (RPC) of a
+// secret
+type PasswordChangeStatus struct {
+	ID                                    int
+	PasswordChangeInProgress, IsOutOfSync bool
+	OutOfSyncReason                       string
+}
+
+// Succeeded reports whether the last password change has completed and the
+// secret is in sync with the target system
+func (p PasswordChangeStatus) Succeeded() bool {
+	return !p.PasswordChangeInProgress && !p.IsOutOfSync
+}
+
+// PasswordChangeFailedError is returned by WaitForPasswordChange when the
+// password change leaves the secret out of sync with the target system
+type PasswordChangeFailedError struct {
+	SecretID int
+	Reason   string
+}
+
+func (e *PasswordChangeFailedError) Error() string {
+	return fmt.Sprintf("the password change of secret %d failed: %s", e.SecretID, e.Reason)
+}
+
+// ChangePassword triggers a remote password change of the secret with id,
+// either to the given NewPassword or to a randomized one. The change happens
+// asynchronously; see PasswordChangeStatus and WaitForPasswordChange.
+func (s Server) ChangePassword(id int, options ChangePasswordOptions) error {
+	input := struct {
+		ChangePasswordOptions
+		GenerateNewPassword bool
+	}{options, options.NewPassword == ""}
+
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/change-password", id), input)
+	return err
+}
+
+// PasswordChangeStatus gets the state of the remote password change of the secret with id
+func (s Server) PasswordChangeStatus(id int) (*PasswordChangeStatus, error) {
+	status := new(PasswordChangeStatus)
+	path := fmt.Sprintf("%d/state", id)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, status); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	status.ID = id
+	return status, nil
+}
+
+// WaitForPasswordChange polls the state of the remote password change of the
+// secret with id every interval until it is no longer in progress or the
+// context is done. It returns a PasswordChangeFailedError if the change left
+// the secret out of sync. LastAttempt is the LastPasswordChangeAttempt of the
+// secret from before the change was triggered: a state that is not in
+// progress is only taken to be that of this change once the change has been
+// seen in progress or, should it have finished before the first poll, once
+// the secret's LastPasswordChangeAttempt differs from lastAttempt. The
+// timestamps are only compared with each other, so the clocks of the Server
+// and the Secret Server need not agree.
+func (s Server) WaitForPasswordChange(ctx context.Context, id int, lastAttempt Time, interval time.Duration) (*PasswordChangeStatus, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seenInProgress := false
+
+	for {
+		status, err := s.PasswordChangeStatus(id)
+
+		if err != nil {
+			return nil, err
+		}
+		if status.PasswordChangeInProgress {
+			seenInProgress = true
+		} else {
+			finished := seenInProgress
+
+			if !finished {
+				attempt, err := s.lastPasswordChangeAttempt(id)
+
+				if err != nil {
+					return nil, err
+				}
+				finished = !attempt.Equal(lastAttempt.Time)
+			}
+			if finished && status.IsOutOfSync {
+				return status, &PasswordChangeFailedError{SecretID: id, Reason: status.OutOfSyncReason}
+			}
+			if finished {
+				return status, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// lastPasswordChangeAttempt gets when a change of the password of the secret
+// with id was last attempted, without downloading its files as Secret does
+func (s Server) lastPasswordChangeAttempt(id int) (Time, error) {
+	var expiration SecretExpiration
+
+	if data, err := s.accessResource("GET", resource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, &expiration); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", resource, id, data)
+			return Time{}, err
+		}
+	} else {
+		return Time{}, err
+	}
+	return expiration.LastPasswordChangeAttempt, nil
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWaitForPasswordChange tests that WaitForPasswordChange polls until the
+// change is no longer in progress and reports a failure as an error
+func TestWaitForPasswordChange(t *testing.T) {
+	var polls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/secrets/1/change-password":
+			atomic.StoreInt32(&polls, 0)
+		case "/api/v1/secrets/1/state":
+			if atomic.AddInt32(&polls, 1) < 3 {
+				fmt.Fprint(w, `{"PasswordChangeInProgress":true}`)
+			} else {
+				fmt.Fprint(w, `{"IsOutOfSync":true,"OutOfSyncReason":"Access denied"}`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	if err := tss.ChangePassword(1, ChangePasswordOptions{}); err != nil {
+		t.Error("calling server.ChangePassword:", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := tss.WaitForPasswordChange(ctx, 1, Time{}, 10*time.Millisecond)
+	if _, ok := err.(*PasswordChangeFailedError); !ok {
+		t.Errorf("expected a PasswordChangeFailedError, but found %v", err)
+		return
+	}
+	validate("polls", int32(3), atomic.LoadInt32(&polls), t)
+	validate("out of sync reason", "Access denied", status.OutOfSyncReason, t)
+}
+
+// TestWaitForPasswordChangeStale tests that WaitForPasswordChange does not
+// take the state of a previous change for that of the one it waits for, and
+// that it notices a change that finished before the first poll whatever the
+// time of the attempt says
+func TestWaitForPasswordChangeStale(t *testing.T) {
+	// attempts in the local time of a Secret Server whose clock is behind
+	lastAttempt := "2000-01-01T10:00:00"
+	var polls int32
+	states := []string{`{"IsOutOfSync":true,"OutOfSyncReason":"stale"}`, `{"PasswordChangeInProgress":true}`, `{}`}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/secrets/1/state":
+			poll := int(atomic.AddInt32(&polls, 1)) - 1
+			if poll >= len(states) {
+				poll = len(states) - 1
+			}
+			fmt.Fprint(w, states[poll])
+		case "/api/v1/secrets/1":
+			fmt.Fprintf(w, `{"ID":1,"LastPasswordChangeAttempt":"%s"}`, lastAttempt)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	secret, err := tss.Secret(1)
+	if err != nil {
+		t.Error("calling server.Secret:", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := tss.WaitForPasswordChange(ctx, 1, secret.LastPasswordChangeAttempt, 10*time.Millisecond); err != nil {
+		t.Error("calling server.WaitForPasswordChange:", err)
+	}
+	validate("polls", int32(3), atomic.LoadInt32(&polls), t)
+
+	// the change finished before the first poll
+	atomic.StoreInt32(&polls, 0)
+	states = states[:1]
+	lastAttempt = "2000-01-01T10:05:00"
+
+	if _, err := tss.WaitForPasswordChange(ctx, 1, secret.LastPasswordChangeAttempt, 10*time.Millisecond); err == nil {
+		t.Error("expected the failure of the change that finished before the first poll")
+	}
+	validate("polls", int32(1), atomic.LoadInt32(&polls), t)
+}
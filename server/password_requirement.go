@@ -0,0 +1,211 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// passwordRequirementResource is the HTTP URL path component for the password requirements resource
+const passwordRequirementResource = "password-requirements"
+
+// defaultPasswordLength is the length of passwords generated for requirements
+// that have no minimum length
+const defaultPasswordLength = 16
+
+// CharacterSet is a named set of characters used in password requirements
+type CharacterSet struct {
+	ID               int
+	Name, Characters string
+}
+
+// CharacterSetRule requires a password to contain at least MinimumCount
+// characters from the CharacterSet
+type CharacterSetRule struct {
+	CharacterSet CharacterSet
+	MinimumCount int
+}
+
+// PasswordRequirement represents the requirements for the value of a password
+// field. A MaximumLength of zero means that there is no maximum, and an empty
+// AllowedCharacterSet allows the characters of all of the rules' sets.
+type PasswordRequirement struct {
+	ID                           int
+	Name, Description            string
+	MinimumLength, MaximumLength int
+	AllowedCharacterSet          CharacterSet
+	CharacterSetRules            []CharacterSetRule
+}
+
+// PasswordValidationError lists the reasons a password does not meet a
+// password requirement
+type PasswordValidationError struct {
+	Requirement string
+	Failures    []string
+}
+
+func (e *PasswordValidationError) Error() string {
+	return fmt.Sprintf("the password does not meet the '%s' requirement: %s", e.Requirement, strings.Join(e.Failures, "; "))
+}
+
+// PasswordRequirement gets the password requirement with id from the Secret Server
+func (s Server) PasswordRequirement(id int) (*PasswordRequirement, error) {
+	requirement := new(PasswordRequirement)
+
+	if data, err := s.accessResource("GET", passwordRequirementResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, requirement); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", passwordRequirementResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return requirement, nil
+}
+
+// FieldPasswordRequirement gets the password requirement of the password field identified by the given slug on the
+// given template.
+func (s Server) FieldPasswordRequirement(slug string, template *SecretTemplate) (*PasswordRequirement, error) {
+	field, found := template.GetField(slug)
+
+	if !found {
+		return nil, errUnknownSlug(slug, template)
+	}
+	if !field.IsPassword || field.PasswordRequirementID == 0 {
+		return nil, fmt.Errorf("the field '%s' on the template named '%s' has no password requirement", slug, template.Name)
+	}
+	return s.PasswordRequirement(field.PasswordRequirementID)
+}
+
+// allowedCharacters returns the characters a password may contain
+func (r PasswordRequirement) allowedCharacters() string {
+	if r.AllowedCharacterSet.Characters != "" {
+		return r.AllowedCharacterSet.Characters
+	}
+
+	var allowed strings.Builder
+
+	for _, rule := range r.CharacterSetRules {
+		for _, c := range rule.CharacterSet.Characters {
+			if !strings.ContainsRune(allowed.String(), c) {
+				allowed.WriteRune(c)
+			}
+		}
+	}
+	return allowed.String()
+}
+
+// Validate checks the password against the requirement locally, returning a
+// PasswordValidationError listing every way in which it falls short
+func (r PasswordRequirement) Validate(password string) error {
+	var failures []string
+
+	length := len([]rune(password))
+
+	if length < r.MinimumLength {
+		failures = append(failures, fmt.Sprintf("it is shorter than %d characters", r.MinimumLength))
+	}
+	if r.MaximumLength > 0 && length > r.MaximumLength {
+		failures = append(failures, fmt.Sprintf("it is longer than %d characters", r.MaximumLength))
+	}
+	if allowed := r.allowedCharacters(); allowed != "" {
+		for _, c := range password {
+			if !strings.ContainsRune(allowed, c) {
+				failures = append(failures, fmt.Sprintf("it contains the disallowed character %q", c))
+			}
+		}
+	}
+	for _, rule := range r.CharacterSetRules {
+		count := 0
+
+		for _, c := range password {
+			if strings.ContainsRune(rule.CharacterSet.Characters, c) {
+				count++
+			}
+		}
+		if count < rule.MinimumCount {
+			failures = append(failures, fmt.Sprintf("it has %d of the required %d characters from '%s'",
+				count, rule.MinimumCount, rule.CharacterSet.Name))
+		}
+	}
+
+	if len(failures) > 0 {
+		return &PasswordValidationError{Requirement: r.Name, Failures: failures}
+	}
+	return nil
+}
+
+// Generate generates a password that meets the requirement locally, using
+// crypto/rand, without calling the Secret Server
+func (r PasswordRequirement) Generate() (string, error) {
+	allowed := []rune(r.allowedCharacters())
+
+	if len(allowed) == 0 {
+		return "", fmt.Errorf("the '%s' requirement allows no characters", r.Name)
+	}
+
+	var password []rune
+
+	for _, rule := range r.CharacterSetRules {
+		characters := []rune(rule.CharacterSet.Characters)
+
+		for i := 0; i < rule.MinimumCount; i++ {
+			c, err := randomRune(characters)
+
+			if err != nil {
+				return "", err
+			}
+			password = append(password, c)
+		}
+	}
+
+	length := r.MinimumLength
+	if length == 0 {
+		length = defaultPasswordLength
+	}
+	if r.MaximumLength > 0 && length > r.MaximumLength {
+		length = r.MaximumLength
+	}
+	for len(password) < length {
+		c, err := randomRune(allowed)
+
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// shuffle the required characters in among the rest (Fisher-Yates)
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+
+	if err := r.Validate(string(password)); err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// randomRune returns a character chosen uniformly at random using crypto/rand
+func randomRune(characters []rune) (rune, error) {
+	if len(characters) == 0 {
+		return 0, fmt.Errorf("a character set of a password requirement is empty")
+	}
+
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(characters))))
+
+	if err != nil {
+		return 0, err
+	}
+	return characters[i.Int64()], nil
+}
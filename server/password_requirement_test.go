@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+)
+
+var testRequirement = PasswordRequirement{
+	Name:          "Test",
+	MinimumLength: 12,
+	MaximumLength: 20,
+	CharacterSetRules: []CharacterSetRule{
+		{CharacterSet: CharacterSet{Name: "Upper", Characters: "ABCDEFGHIJKLMNOPQRSTUVWXYZ"}, MinimumCount: 2},
+		{CharacterSet: CharacterSet{Name: "Lower", Characters: "abcdefghijklmnopqrstuvwxyz"}, MinimumCount: 2},
+		{CharacterSet: CharacterSet{Name: "Digits", Characters: "0123456789"}, MinimumCount: 2},
+		{CharacterSet: CharacterSet{Name: "Symbols", Characters: "!@#$%"}, MinimumCount: 1},
+	},
+}
+
+// TestPasswordRequirementValidate tests that Validate reports each failure
+func TestPasswordRequirementValidate(t *testing.T) {
+	if err := testRequirement.Validate("ABcd12!efghij"); err != nil {
+		t.Error("expected the password to be valid:", err)
+	}
+
+	err := testRequirement.Validate("abc 1")
+	if err == nil {
+		t.Error("expected the password to be invalid")
+		return
+	}
+	validationError, ok := err.(*PasswordValidationError)
+	if !ok {
+		t.Errorf("expected a PasswordValidationError, but found %T", err)
+		return
+	}
+	// too short, the space, and the upper case, digit and symbol counts
+	validate("failure count", 5, len(validationError.Failures), t)
+}
+
+// TestPasswordRequirementGenerate tests that Generate produces passwords that
+// meet the requirement
+func TestPasswordRequirementGenerate(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		password, err := testRequirement.Generate()
+		if err != nil {
+			t.Error("calling Generate:", err)
+			return
+		}
+		validate("password length", 12, len(password), t)
+	}
+
+	if _, err := (PasswordRequirement{Name: "Empty"}).Generate(); err == nil {
+		t.Error("expected an error generating a password that allows no characters")
+	}
+}
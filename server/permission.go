@@ -0,0 +1,163 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// secretPermissionResource is the HTTP URL path component for the secret permissions resource
+	secretPermissionResource = "secret-permissions"
+	// folderPermissionResource is the HTTP URL path component for the folder permissions resource
+	folderPermissionResource = "folder-permissions"
+	// folderResource is the HTTP URL path component for the folders resource
+	folderResource = "folders"
+)
+
+// The built-in access roles of secrets and folders
+const (
+	RoleList  = "List"
+	RoleView  = "View"
+	RoleEdit  = "Edit"
+	RoleOwner = "Owner"
+	// RoleAddSecret is a folder access role allowing secrets to be created
+	RoleAddSecret = "Add Secret"
+)
+
+// SecretPermission grants a user or, if the UserID is zero, a group access to
+// a secret in the given role
+type SecretPermission struct {
+	ID                   int `json:",omitempty"`
+	SecretID             int
+	UserID, GroupID      int `json:",omitempty"`
+	UserName, GroupName  string
+	SecretAccessRoleName string
+}
+
+// FolderPermission grants a user or, if the UserID is zero, a group access to
+// a folder, and to the secrets in it, in the given roles
+type FolderPermission struct {
+	ID                                         int `json:",omitempty"`
+	FolderID                                   int
+	UserID, GroupID                            int `json:",omitempty"`
+	UserName, GroupName                        string
+	FolderAccessRoleName, SecretAccessRoleName string
+}
+
+// SecretPermissions lists the permissions on the secret with id
+func (s Server) SecretPermissions(id int) ([]SecretPermission, error) {
+	var permissions []SecretPermission
+
+	query := url.Values{"filter.secretId": {strconv.Itoa(id)}}
+
+	if err := s.listRecords(secretPermissionResource, "", query, Paging{}, &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// AddSecretPermission grants the permission and returns it as created
+func (s Server) AddSecretPermission(permission SecretPermission) (*SecretPermission, error) {
+	written := new(SecretPermission)
+	if err := s.writeResource("POST", secretPermissionResource, "/", permission, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// UpdateSecretPermission changes the role of the permission with the given ID
+func (s Server) UpdateSecretPermission(permission SecretPermission) (*SecretPermission, error) {
+	written := new(SecretPermission)
+	if err := s.writeResource("PUT", secretPermissionResource, strconv.Itoa(permission.ID), permission, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// RemoveSecretPermission revokes the secret permission with id
+func (s Server) RemoveSecretPermission(id int) error {
+	_, err := s.accessResource("DELETE", secretPermissionResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// SetSecretInheritPermissions sets whether the secret with id inherits the
+// permissions of its folder
+func (s Server) SetSecretInheritPermissions(id int, inherit bool) error {
+	input := struct{ InheritPermissions bool }{inherit}
+
+	_, err := s.accessResource("PUT", resource, fmt.Sprintf("%d/share", id), input)
+	return err
+}
+
+// FolderPermissions lists the permissions on the folder with id
+func (s Server) FolderPermissions(id int) ([]FolderPermission, error) {
+	var permissions []FolderPermission
+
+	query := url.Values{"filter.folderId": {strconv.Itoa(id)}}
+
+	if err := s.listRecords(folderPermissionResource, "", query, Paging{}, &permissions); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// AddFolderPermission grants the permission and returns it as created
+func (s Server) AddFolderPermission(permission FolderPermission) (*FolderPermission, error) {
+	written := new(FolderPermission)
+	if err := s.writeResource("POST", folderPermissionResource, "/", permission, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// UpdateFolderPermission changes the roles of the permission with the given ID
+func (s Server) UpdateFolderPermission(permission FolderPermission) (*FolderPermission, error) {
+	written := new(FolderPermission)
+	if err := s.writeResource("PUT", folderPermissionResource, strconv.Itoa(permission.ID), permission, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// RemoveFolderPermission revokes the folder permission with id
+func (s Server) RemoveFolderPermission(id int) error {
+	_, err := s.accessResource("DELETE", folderPermissionResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// SetFolderInheritPermissions sets whether the folder with id inherits the
+// permissions of its parent folder
+func (s Server) SetFolderInheritPermissions(id int, inherit bool) error {
+	return s.updateFolder(id, map[string]interface{}{"inheritPermissions": inherit})
+}
+
+// updateFolder changes the given settings of the folder with id. The folder
+// model is round-tripped as is so that only those settings change.
+func (s Server) updateFolder(id int, settings map[string]interface{}) error {
+	folder := make(map[string]interface{})
+
+	if data, err := s.accessResource("GET", folderResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, &folder); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", folderResource, id, data)
+			return err
+		}
+	} else {
+		return err
+	}
+
+	for name, value := range settings {
+		for key := range folder {
+			if strings.EqualFold(key, name) {
+				delete(folder, key)
+			}
+		}
+		folder[name] = value
+	}
+
+	_, err := s.accessResource("PUT", folderResource, strconv.Itoa(id), folder)
+	return err
+}
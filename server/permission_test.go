@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSetFolderInheritPermissions tests that the other settings of the folder
+// are sent back unchanged and that the setting replaces the folder's setting
+// whatever its case
+func TestSetFolderInheritPermissions(t *testing.T) {
+	var update map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/folders/5":
+			fmt.Fprint(w, `{"id":5,"folderName":"Ops","parentFolderId":1,"InheritPermissions":false,"secretPolicyId":3}`)
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/folders/5":
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				t.Error("parsing the update:", err)
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	if err := tss.SetFolderInheritPermissions(5, true); err != nil {
+		t.Error("calling server.SetFolderInheritPermissions:", err)
+		return
+	}
+
+	validate("ID", 5.0, update["id"], t)
+	validate("folder name", "Ops", update["folderName"], t)
+	validate("parent folder ID", 1.0, update["parentFolderId"], t)
+	validate("secret policy ID", 3.0, update["secretPolicyId"], t)
+	validate("inherit permissions", true, update["inheritPermissions"], t)
+	if _, ok := update["InheritPermissions"]; ok {
+		t.Error("expected the folder's setting to be replaced rather than sent twice")
+	}
+	validate("setting count", 5, len(update), t)
+}
+
+// TestPermissionWrites tests the methods, paths and bodies of the calls that
+// change permissions
+func TestPermissionWrites(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		fmt.Fprint(w, `{"ID":7}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	secretPermission := SecretPermission{SecretID: 1, GroupID: 2, SecretAccessRoleName: RoleView}
+	folderPermission := FolderPermission{FolderID: 5, UserID: 3, FolderAccessRoleName: RoleAddSecret, SecretAccessRoleName: RoleEdit}
+
+	if written, err := tss.AddSecretPermission(secretPermission); err != nil {
+		t.Error("calling server.AddSecretPermission:", err)
+	} else {
+		validate("secret permission ID", 7, written.ID, t)
+	}
+	secretPermission.ID, secretPermission.SecretAccessRoleName = 7, RoleOwner
+	if _, err := tss.UpdateSecretPermission(secretPermission); err != nil {
+		t.Error("calling server.UpdateSecretPermission:", err)
+	}
+	if err := tss.RemoveSecretPermission(7); err != nil {
+		t.Error("calling server.RemoveSecretPermission:", err)
+	}
+	if err := tss.SetSecretInheritPermissions(1, false); err != nil {
+		t.Error("calling server.SetSecretInheritPermissions:", err)
+	}
+	if _, err := tss.AddFolderPermission(folderPermission); err != nil {
+		t.Error("calling server.AddFolderPermission:", err)
+	}
+	folderPermission.ID = 8
+	if _, err := tss.UpdateFolderPermission(folderPermission); err != nil {
+		t.Error("calling server.UpdateFolderPermission:", err)
+	}
+	if err := tss.RemoveFolderPermission(8); err != nil {
+		t.Error("calling server.RemoveFolderPermission:", err)
+	}
+
+	expected := []string{
+		`POST /api/v1/secret-permissions/ {"SecretID":1,"GroupID":2,"UserName":"","GroupName":"","SecretAccessRoleName":"View"}`,
+		`PUT /api/v1/secret-permissions/7 {"ID":7,"SecretID":1,"GroupID":2,"UserName":"","GroupName":"","SecretAccessRoleName":"Owner"}`,
+		`DELETE /api/v1/secret-permissions/7 `,
+		`PUT /api/v1/secrets/1/share {"InheritPermissions":false}`,
+		`POST /api/v1/folder-permissions/ {"FolderID":5,"UserID":3,"UserName":"","GroupName":"","FolderAccessRoleName":"Add Secret","SecretAccessRoleName":"Edit"}`,
+		`PUT /api/v1/folder-permissions/8 {"ID":8,"FolderID":5,"UserID":3,"UserName":"","GroupName":"","FolderAccessRoleName":"Add Secret","SecretAccessRoleName":"Edit"}`,
+		`DELETE /api/v1/folder-permissions/8 `,
+	}
+	if !validate("request count", len(expected), len(requests), t) {
+		return
+	}
+	for i, request := range requests {
+		validate("request", expected[i], request, t)
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The object identifiers of the PKCS #5 v2 (RFC 8018) algorithms that encrypt
+// PKCS #8 private keys
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// maxPBKDF2Iterations limits the work that decrypting a key may take, so that
+// a corrupt or hostile key cannot hang the caller
+const maxPBKDF2Iterations = 10000000
+
+// encryptedPrivateKeyInfo is an encrypted PKCS #8 private key
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of the PBES2 encryption scheme
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of the PBKDF2 key derivation function. The
+// PRF defaults to HMAC-SHA1.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts an encrypted PKCS #8 private key, returning the DER of
+// the unencrypted key. Only PBES2 with PBKDF2 (HMAC-SHA1 or HMAC-SHA256) and
+// AES-CBC, which OpenSSL uses by default, is supported.
+func decryptPKCS8(der []byte, passphrase string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("the encryption algorithm %s is not supported", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("the key derivation function %s is not supported", params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+
+	if kdf.IterationCount <= 0 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("the PBKDF2 iteration count %d is not between 1 and %d", kdf.IterationCount, maxPBKDF2Iterations)
+	}
+
+	var prf func() hash.Hash
+
+	switch {
+	case len(kdf.PRF.Algorithm) == 0, kdf.PRF.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdf.PRF.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	default:
+		return nil, fmt.Errorf("the pseudorandom function %s is not supported", kdf.PRF.Algorithm)
+	}
+
+	var keyLength int
+
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keyLength = 16
+	case scheme.Equal(oidAES192CBC):
+		keyLength = 24
+	case scheme.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		return nil, fmt.Errorf("the encryption scheme %s is not supported", scheme)
+	}
+
+	var iv []byte
+
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), kdf.Salt, kdf.IterationCount, keyLength, prf))
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := info.EncryptedData
+
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("the encrypted private key is malformed")
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	// a wrong passphrase shows as invalid PKCS #7 padding
+	padding := int(decrypted[len(decrypted)-1])
+
+	if padding == 0 || padding > aes.BlockSize ||
+		!bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, x509.IncorrectPasswordError
+	}
+	return decrypted[:len(decrypted)-padding], nil
+}
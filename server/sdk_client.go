@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// sdkClientResource is the HTTP URL path component for the SDK client accounts resource
+const sdkClientResource = "sdk-client-accounts"
+
+// ClientRegistration holds the onboarding rule name and key that an SDK
+// client uses to register itself with Secret Server. The ClientID is
+// generated and the Name defaults to the host name when they are empty.
+type ClientRegistration struct {
+	RuleName, OnboardingKey string
+	ClientID, Name          string
+	Description             string
+}
+
+// ClientCredential is the client ID and secret issued to a registered SDK
+// client. It authenticates with the OAuth2 client_credentials grant.
+type ClientCredential struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+}
+
+// Grant requests an access grant with the client ID and secret
+func (c ClientCredential) Grant(tokenURL string) (*AccessGrant, error) {
+	return requestGrant(tokenURL, url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"grant_type":    {"client_credentials"},
+	}, nil)
+}
+
+// RegisterClient registers an SDK client account using the onboarding rule
+// name and key, and returns the credential issued for it. The request is not
+// authenticated; the onboarding key authorizes it. It fails over to the next
+// endpoint only when the registration could not be sent.
+func (s Server) RegisterClient(registration ClientRegistration) (*ClientCredential, error) {
+	if registration.RuleName == "" || registration.OnboardingKey == "" {
+		return nil, fmt.Errorf("the onboarding rule name and key must be set")
+	}
+	if registration.ClientID == "" {
+		id := make([]byte, 16)
+
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		registration.ClientID = "sdk-client-" + hex.EncodeToString(id)
+	}
+	if registration.Name == "" {
+		name, err := os.Hostname()
+
+		if err != nil {
+			return nil, fmt.Errorf("the SDK client name is not set and the host name is unavailable: %s", err)
+		}
+		registration.Name = name
+	}
+
+	body, err := json.Marshal(registration)
+
+	if err != nil {
+		log.Print("[ERROR] marshaling the request body to JSON:", err)
+		return nil, err
+	}
+
+	data, err := s.withFailover(context.Background(), "POST", func(baseURL string) ([]byte, error) {
+		req, err := http.NewRequest("POST", s.endpointURLFor(baseURL, sdkClientResource, ""), bytes.NewBuffer(body))
+
+		if err != nil {
+			log.Printf("[ERROR] creating req: POST /%s: %s", sdkClientResource, err)
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		log.Printf("[DEBUG] registering SDK client '%s' with POST %s", registration.ClientID, req.URL.String())
+
+		data, _, err := handleResponse((&http.Client{}).Do(req))
+
+		return data, err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	credential := &ClientCredential{ClientID: registration.ClientID}
+
+	if err = json.Unmarshal(data, credential); err != nil {
+		log.Printf("[ERROR] error parsing response from /%s: %s", sdkClientResource, err)
+		return nil, err
+	}
+	if credential.ClientSecret == "" {
+		return nil, fmt.Errorf("no client secret was issued for SDK client '%s'", credential.ClientID)
+	}
+	return credential, nil
+}
+
+// OnboardClient returns the client credential persisted in the file at path,
+// or, when there is none, registers a new SDK client and persists the
+// credential issued for it to the file.
+func (s Server) OnboardClient(path string, registration ClientRegistration) (*ClientCredential, error) {
+	credential, err := LoadClientCredential(path)
+
+	if err == nil {
+		return credential, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if credential, err = s.RegisterClient(registration); err != nil {
+		return nil, err
+	}
+	if err = SaveClientCredential(path, *credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// LoadClientCredential reads a client credential from the JSON file at path
+func LoadClientCredential(path string) (*ClientCredential, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	credential := new(ClientCredential)
+
+	if err = json.Unmarshal(data, credential); err != nil {
+		log.Printf("[ERROR] error parsing client credential file '%s': %s", path, err)
+		return nil, err
+	}
+	return credential, nil
+}
+
+// SaveClientCredential writes the client credential to the JSON file at path,
+// readable and writable only by the owner. The file is written to a temporary
+// file in the same directory first and renamed into place, so that it is never
+// left partially written or readable by others.
+func SaveClientCredential(path string, credential ClientCredential) error {
+	data, err := json.Marshal(credential)
+
+	if err != nil {
+		return err
+	}
+
+	// TempFile creates the file readable and writable only by the owner
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
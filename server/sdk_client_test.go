@@ -0,0 +1,162 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRegisterClient tests that RegisterClient sends the registration and
+// fails when no client secret is issued
+func TestRegisterClient(t *testing.T) {
+	clientSecret := "s3cr3t"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/sdk-client-accounts/" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("expected the registration not to be authenticated")
+		}
+
+		var registration ClientRegistration
+
+		if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+			t.Error("parsing the registration:", err)
+		}
+		validate("rule name", "rule", registration.RuleName, t)
+		validate("onboarding key", "k3y", registration.OnboardingKey, t)
+		validate("client ID", "client", registration.ClientID, t)
+		validate("name", "host", registration.Name, t)
+		fmt.Fprintf(w, `{"clientSecret":"%s"}`, clientSecret)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	registration := ClientRegistration{RuleName: "rule", OnboardingKey: "k3y", ClientID: "client", Name: "host"}
+
+	credential, err := tss.RegisterClient(registration)
+	if err != nil {
+		t.Error("calling server.RegisterClient:", err)
+		return
+	}
+	validate("client ID", "client", credential.ClientID, t)
+	validate("client secret", "s3cr3t", credential.ClientSecret, t)
+
+	clientSecret = ""
+	if _, err := tss.RegisterClient(registration); err == nil {
+		t.Error("expected an error when no client secret is issued")
+	}
+}
+
+// TestRegisterClientFailover tests that RegisterClient sends the registration
+// to the next endpoint when the first one cannot be reached
+func TestRegisterClientFailover(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"clientSecret":"s3cr3t"}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURLs: []string{down.URL, ts.URL}})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	defer tss.Close()
+
+	registration := ClientRegistration{RuleName: "rule", OnboardingKey: "k3y", ClientID: "client", Name: "host"}
+
+	credential, err := tss.RegisterClient(registration)
+	if err != nil {
+		t.Error("calling server.RegisterClient:", err)
+		return
+	}
+	validate("client secret", "s3cr3t", credential.ClientSecret, t)
+	validate("preferred endpoint", ts.URL, tss.preferredBaseURL(), t)
+}
+
+// TestOnboardClient tests that OnboardClient registers a client and saves its
+// credential only when no credential has been saved
+func TestOnboardClient(t *testing.T) {
+	registrations := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registrations++
+		fmt.Fprint(w, `{"clientSecret":"s3cr3t"}`)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "tss-sdk-go")
+	if err != nil {
+		t.Error("creating a directory:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	path := filepath.Join(dir, "credential.json")
+	registration := ClientRegistration{RuleName: "rule", OnboardingKey: "k3y", ClientID: "client"}
+
+	for i := 0; i < 2; i++ {
+		credential, err := tss.OnboardClient(path, registration)
+		if err != nil {
+			t.Error("calling server.OnboardClient:", err)
+			return
+		}
+		validate("client ID", "client", credential.ClientID, t)
+		validate("client secret", "s3cr3t", credential.ClientSecret, t)
+	}
+	validate("registrations", 1, registrations, t)
+
+	if info, err := os.Stat(path); err != nil {
+		t.Error("reading the credential file:", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the credential file to be private, but its mode is %v", info.Mode())
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expected only the credential file, but found %d files", len(files))
+	}
+}
+
+// TestClientCredentialGrant tests that a ClientCredential requests a grant
+// with the client_credentials grant type
+func TestClientCredentialGrant(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error("parsing the token request:", err)
+		}
+		if !validate("grant type", "client_credentials", r.PostForm.Get("grant_type"), t) ||
+			!validate("client ID", "client", r.PostForm.Get("client_id"), t) ||
+			!validate("client secret", "s3cr3t", r.PostForm.Get("client_secret"), t) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","expires_in":1200}`)
+	}))
+	defer ts.Close()
+
+	grant, err := ClientCredential{ClientID: "client", ClientSecret: "s3cr3t"}.Grant(ts.URL + "/oauth2/token")
+	if err != nil {
+		t.Error("calling ClientCredential.Grant:", err)
+		return
+	}
+	validate("access token", "t0k3n", grant.AccessToken, t)
+}
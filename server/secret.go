@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -21,6 +22,7 @@ type Secret struct {
 	EnableInheritPermissions, EnableInheritSecretPolicy, ProxyEnabled          bool
 	RequiresComment, SessionRecordingEnabled, WebLauncherRequiresIncognitoMode bool
 	Fields                                                                     []SecretField `json:"Items"`
+	SecretExpiration
 }
 
 // SecretField is an item (field) in the secret
@@ -33,24 +35,45 @@ type SecretField struct {
 
 // Secret gets the secret with id from the Secret Server of the given tenant
 func (s Server) Secret(id int) (*Secret, error) {
+	return s.readSecret(id, false)
+}
+
+// SecretIncludingInactive gets the secret with id, as Secret does, even if it
+// has been deactivated
+func (s Server) SecretIncludingInactive(id int) (*Secret, error) {
+	return s.readSecret(id, true)
+}
+
+func (s Server) readSecret(id int, includeInactive bool) (*Secret, error) {
+	return s.readSecretContext(context.Background(), id, includeInactive)
+}
+
+// readSecretContext reads the secret with id, as readSecret does, for as long
+// as the context allows
+func (s Server) readSecretContext(ctx context.Context, id int, includeInactive bool) (*Secret, error) {
 	secret := new(Secret)
+	query := ""
 
-	if data, err := s.accessResource("GET", resource, strconv.Itoa(id), nil); err == nil {
+	if includeInactive {
+		query = "?includeInactive=true"
+	}
+
+	if data, err := s.accessResourceContext(ctx, "GET", resource, strconv.Itoa(id)+query, nil); err == nil {
 		if err = json.Unmarshal(data, secret); err != nil {
 			log.Printf("[ERROR] error parsing response from /%s/%d: %q", resource, id, data)
 			return nil, err
 		}
 	} else {
-		return nil, err
+		return nil, requiresApproval(id, err)
 	}
 
 	// automatically download file attachments and substitute them for the
 	// (dummy) ItemValue, so as to make the process transparent to the caller
 	for index, element := range secret.Fields {
 		if element.IsFile && element.FileAttachmentID != 0 && element.Filename != "" {
-			path := fmt.Sprintf("%d/fields/%s", id, element.Slug)
+			path := fmt.Sprintf("%d/fields/%s%s", id, element.Slug, query)
 
-			if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+			if data, err := s.accessResourceContext(ctx, "GET", resource, path, nil); err == nil {
 				secret.Fields[index].ItemValue = string(data)
 			} else {
 				return nil, err
@@ -69,6 +92,14 @@ func (s Server) UpdateSecret(secret Secret) (*Secret, error) {
 	return s.writeSecret(secret, "PUT", strconv.Itoa(secret.ID))
 }
 
+// secretWrite is the body with which a secret is written. The expiration of a
+// secret is computed by the Secret Server, so its fields, which shadow those
+// of the embedded SecretExpiration, are left out.
+type secretWrite struct {
+	Secret
+	ExpirationDate, LastPasswordChangeAttempt, DaysUntilExpiration *struct{} `json:",omitempty"`
+}
+
 func (s Server) writeSecret(secret Secret, method string, path string) (*Secret, error) {
 	writtenSecret := new(Secret)
 
@@ -83,7 +114,7 @@ func (s Server) writeSecret(secret Secret, method string, path string) (*Secret,
 	}
 	secret.Fields = nonFileFields
 
-	if data, err := s.accessResource(method, resource, path, secret); err == nil {
+	if data, err := s.accessResource(method, resource, path, secretWrite{Secret: secret}); err == nil {
 		if err = json.Unmarshal(data, writtenSecret); err != nil {
 			log.Printf("[ERROR] error parsing response from /%s: %q", resource, data)
 			return nil, err
@@ -99,11 +130,29 @@ func (s Server) writeSecret(secret Secret, method string, path string) (*Secret,
 	return s.Secret(writtenSecret.ID)
 }
 
+// DeleteSecret deletes the secret with id. Secret Server keeps deleted secrets
+// as inactive, so this is the same as DeactivateSecret.
 func (s Server) DeleteSecret(id int) error {
+	return s.DeactivateSecret(id)
+}
+
+// DeactivateSecret deactivates the secret with id, which hides it from Secret
+// and from searches that do not include inactive secrets, until it is
+// reactivated
+func (s Server) DeactivateSecret(id int) error {
 	_, err := s.accessResource("DELETE", resource, strconv.Itoa(id), nil)
 	return err
 }
 
+// ReactivateSecret reactivates (undeletes) the secret with id, which was
+// deactivated or deleted, and returns it
+func (s Server) ReactivateSecret(id int) (*Secret, error) {
+	if _, err := s.accessResource("POST", resource, fmt.Sprintf("%d/undelete", id), nil); err != nil {
+		return nil, err
+	}
+	return s.Secret(id)
+}
+
 // Field returns the value of the field with the name fieldName
 func (s Secret) Field(fieldName string) (string, bool) {
 	for _, field := range s.Fields {
@@ -132,6 +181,23 @@ func (s Secret) FieldById(fieldId int) (string, bool) {
 // deletes the file, otherwise, uploads the contents of the item value as the new/updated
 // file attachment.
 func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
+	for _, element := range fileFields {
+		if element.ItemValue == "" {
+			if err := s.patchField(secretId, element.Slug, nil); err != nil {
+				return err
+			}
+		} else {
+			if err := s.uploadFile(secretId, element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// patchField sets the value of the field with the given slug on the secret at the given secretId, leaving its other
+// fields as they are. A nil value clears the field, deleting the file of a file field.
+func (s Server) patchField(secretId int, slug string, value interface{}) error {
 	type fieldMod struct {
 		Slug                     string
 		Dirty                    bool
@@ -146,22 +212,10 @@ func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
 		Data                     fieldMods
 	}
 
-	for _, element := range fileFields {
-		var path string
-		var input interface{}
-		if element.ItemValue == "" {
-			path = fmt.Sprintf("%d/general", secretId)
-			input = secretPatch{ Data: fieldMods{ SecretFields: []fieldMod{{ Slug: element.Slug, Dirty: true, Value: nil }} } }
-			if _, err := s.accessResource("PATCH", resource, path, input); err != nil {
-				return err
-			}
-		} else {
-			if err := s.uploadFile(secretId, element); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	path := fmt.Sprintf("%d/general", secretId)
+	input := secretPatch{ Data: fieldMods{ SecretFields: []fieldMod{{ Slug: slug, Dirty: true, Value: value }} } }
+	_, err := s.accessResource("PATCH", resource, path, input)
+	return err
 }
 
 // separateFileFields iterates the fields on this secret, and separates them into file
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// auditResource is the HTTP URL path component for the secret audits resource
+const auditResource = "secret-audits"
+
+// SecretAudit is an entry in the audit trail of a secret, recording who did
+// what to it, when and from where
+type SecretAudit struct {
+	SecretAuditID, SecretID, UserID  int
+	Action, ByUserDisplayName, Notes string
+	IPAddress, MachineName           string
+	DateRecorded                     Time
+}
+
+// AuditFilter selects the audit entries recorded between StartDate and
+// EndDate, either of which may be zero to leave the range open. The FolderID
+// and IncludeSubFolders restrict Audits to the secrets in a folder.
+type AuditFilter struct {
+	Paging
+	StartDate, EndDate time.Time
+	FolderID           int
+	IncludeSubFolders  bool
+}
+
+// query returns the filter as query parameters
+func (f AuditFilter) query() url.Values {
+	query := url.Values{}
+
+	if !f.StartDate.IsZero() {
+		query.Set("filter.startDate", f.StartDate.Format(time.RFC3339))
+	}
+	if !f.EndDate.IsZero() {
+		query.Set("filter.endDate", f.EndDate.Format(time.RFC3339))
+	}
+	if f.FolderID != 0 {
+		query.Set("filter.folderId", strconv.Itoa(f.FolderID))
+		query.Set("filter.includeSubFolders", strconv.FormatBool(f.IncludeSubFolders))
+	}
+	return query
+}
+
+// SecretAudits gets the audit trail of the secret with id, filtered by date
+// and paged according to the filter. The filter's folder is ignored.
+func (s Server) SecretAudits(id int, filter AuditFilter) ([]SecretAudit, error) {
+	filter.FolderID = 0
+	return s.listAudits(resource, fmt.Sprintf("%d/audits", id), filter)
+}
+
+// Audits gets the audit entries of all of the secrets, or of those in the
+// filter's folder, filtered by date and paged according to the filter.
+func (s Server) Audits(filter AuditFilter) ([]SecretAudit, error) {
+	return s.listAudits(auditResource, "", filter)
+}
+
+func (s Server) listAudits(resource, path string, filter AuditFilter) ([]SecretAudit, error) {
+	var audits []SecretAudit
+
+	if err := s.listRecords(resource, path, filter.query(), filter.Paging, &audits); err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestAuditFilter tests the query parameters that each field of an
+// AuditFilter produces, and that SecretAudits ignores the folder
+func TestAuditFilter(t *testing.T) {
+	var path string
+	var query url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		fmt.Fprint(w, `{"Records":[{"SecretAuditID":1,"Action":"VIEW"}]}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 2, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+	filter := AuditFilter{StartDate: start, EndDate: end, FolderID: 5, IncludeSubFolders: true}
+
+	if _, err := tss.Audits(filter); err != nil {
+		t.Error("calling server.Audits:", err)
+		return
+	}
+	validate("path", "/api/v1/secret-audits/", path, t)
+	validate("start date", "2021-01-01T00:00:00Z", query.Get("filter.startDate"), t)
+	validate("end date", "2021-02-01T12:30:00+01:00", query.Get("filter.endDate"), t)
+	validate("folder id", "5", query.Get("filter.folderId"), t)
+	validate("include sub-folders", "true", query.Get("filter.includeSubFolders"), t)
+
+	if _, err := tss.Audits(AuditFilter{FolderID: 5}); err != nil {
+		t.Error("calling server.Audits:", err)
+		return
+	}
+	validate("include sub-folders", "false", query.Get("filter.includeSubFolders"), t)
+
+	if _, err := tss.SecretAudits(1, filter); err != nil {
+		t.Error("calling server.SecretAudits:", err)
+		return
+	}
+	validate("path", "/api/v1/secrets/1/audits", path, t)
+	validate("start date", "2021-01-01T00:00:00Z", query.Get("filter.startDate"), t)
+	for _, key := range []string{"filter.folderId", "filter.includeSubFolders"} {
+		if _, ok := query[key]; ok {
+			t.Errorf("expected no %s for the audits of a secret", key)
+		}
+	}
+
+	if _, err := tss.Audits(AuditFilter{}); err != nil {
+		t.Error("calling server.Audits:", err)
+		return
+	}
+	for key := range query {
+		if key != "skip" && key != "take" {
+			t.Errorf("expected no %s for an empty filter", key)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// FieldNotFileError is returned when a field that should hold a file, such as
+// a certificate or a private key, is not a file field
+type FieldNotFileError struct {
+	Secret, Slug string
+}
+
+func (e *FieldNotFileError) Error() string {
+	return fmt.Sprintf("the field '%s' of the secret named '%s' is not a file", e.Slug, e.Secret)
+}
+
+// FieldDecodeError is returned when the file in a field cannot be decoded as
+// the Format, e.g. a certificate or a private key
+type FieldDecodeError struct {
+	Secret, Slug, Format string
+	Err                  error
+}
+
+func (e *FieldDecodeError) Error() string {
+	return fmt.Sprintf("the file in the field '%s' of the secret named '%s' is not a valid %s: %s",
+		e.Slug, e.Secret, e.Format, e.Err)
+}
+
+func (e *FieldDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// TLSCertificate returns the certificate chain in the file field certSlug,
+// leaf first, paired with the private key in the file field keySlug. The
+// certificates may be PEM or DER encoded. An empty keySlug means that the key
+// is in the same file as the certificates. Keys that are encrypted are not
+// supported; use PrivateKey instead.
+func (s Secret) TLSCertificate(certSlug, keySlug string) (tls.Certificate, error) {
+	certData, err := s.file(certSlug)
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certs, err := parseCertificates(certData)
+
+	if err != nil {
+		return tls.Certificate{}, s.decodeError(certSlug, "certificate", err)
+	}
+
+	if keySlug == "" {
+		keySlug = certSlug
+	}
+
+	key, err := s.PrivateKey(keySlug, "")
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certificate, err := keyPair(key, certs)
+
+	if err != nil {
+		return tls.Certificate{}, s.decodeError(certSlug, "certificate", err)
+	}
+	return certificate, nil
+}
+
+// PrivateKey returns the private key in the file field slug, decrypting it
+// with the value of the field passphraseSlug if it is encrypted. The key may be
+// PKCS #1, PKCS #8 or SEC 1 (EC) encoded, in PEM or DER. Encrypted PEM keys
+// may be encrypted PKCS #8 (PBES2 with AES), as OpenSSL writes by default, or
+// use the legacy OpenSSL encryption with a DEK-Info header. An empty
+// passphraseSlug means that there is no passphrase. The key is an
+// *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+func (s Secret) PrivateKey(slug, passphraseSlug string) (crypto.PrivateKey, error) {
+	data, err := s.file(slug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := s.passphrase(passphraseSlug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePrivateKey(data, passphrase)
+
+	if err != nil {
+		return nil, s.decodeError(slug, "private key", err)
+	}
+	return key, nil
+}
+
+// PKCS12 returns the certificate chain, leaf first, and the private key in the
+// PKCS #12 (.pfx or .p12) file in the file field slug, decrypting it with the
+// value of the field passwordSlug. An empty passwordSlug means that the
+// password is empty. Only the legacy encryption algorithms (3DES and RC2) are
+// supported.
+func (s Secret) PKCS12(slug, passwordSlug string) (tls.Certificate, error) {
+	data, err := s.file(slug)
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	password, err := s.passphrase(passwordSlug)
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certificate, err := parsePKCS12(data, password)
+
+	if err != nil {
+		return tls.Certificate{}, s.decodeError(slug, "PKCS #12 file", err)
+	}
+	return certificate, nil
+}
+
+// file returns the content of the file field with the given slug or name
+func (s Secret) file(slug string) ([]byte, error) {
+	for _, field := range s.Fields {
+		if slug == field.FieldName || slug == field.Slug {
+			if !field.IsFile {
+				return nil, &FieldNotFileError{Secret: s.Name, Slug: slug}
+			}
+			if field.ItemValue == "" {
+				return nil, s.decodeError(slug, "file", errors.New("the file is empty"))
+			}
+			return []byte(field.ItemValue), nil
+		}
+	}
+	return nil, errUnknownField(slug, s)
+}
+
+// passphrase returns the value of the field with the given slug or name, or
+// an empty passphrase if the slug is empty
+func (s Secret) passphrase(slug string) (string, error) {
+	if slug == "" {
+		return "", nil
+	}
+	if value, ok := s.Field(slug); ok {
+		return value, nil
+	}
+	return "", errUnknownField(slug, s)
+}
+
+func (s Secret) decodeError(slug, format string, err error) error {
+	return &FieldDecodeError{Secret: s.Name, Slug: slug, Format: format, Err: err}
+}
+
+// errUnknownField is the error for a slug that does not identify a field of the secret
+func errUnknownField(slug string, secret Secret) error {
+	return fmt.Errorf("the alias '%s' does not identify a field of the secret named '%s'", slug, secret.Name)
+}
+
+// parseCertificates parses the PEM CERTIFICATE blocks in data or, if there are
+// none, data as DER encoded certificates
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for rest := data; ; {
+		var block *pem.Block
+
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return nil, errors.New("there is no PEM CERTIFICATE block")
+	}
+	return x509.ParseCertificates(data)
+}
+
+// parsePrivateKey parses the first PEM PRIVATE KEY block in data, decrypting
+// it with the passphrase if it is encrypted, or, if there is none, data as a
+// DER encoded key
+func parsePrivateKey(data []byte, passphrase string) (crypto.PrivateKey, error) {
+	var block *pem.Block
+
+	for rest := data; ; {
+		if block, rest = pem.Decode(rest); block == nil || strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			break
+		}
+	}
+
+	if block == nil {
+		if bytes.Contains(data, []byte("-----BEGIN")) {
+			return nil, errors.New("there is no PEM PRIVATE KEY block")
+		}
+		return parseDERPrivateKey(data)
+	}
+	if block.Type == "OPENSSH PRIVATE KEY" {
+		return nil, errors.New("OpenSSH private keys are not supported")
+	}
+
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY"
+	legacy := x509.IsEncryptedPEMBlock(block)
+
+	if (encrypted || legacy) && passphrase == "" {
+		return nil, errors.New("the private key is encrypted but there is no passphrase")
+	}
+
+	der := block.Bytes
+	var err error
+
+	switch {
+	case encrypted:
+		der, err = decryptPKCS8(der, passphrase)
+	case legacy:
+		// the legacy OpenSSL encryption, with a DEK-Info header, is insecure
+		// and deprecated, but older tools still write it
+		der, err = x509.DecryptPEMBlock(block, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseDERPrivateKey(der)
+}
+
+// parseDERPrivateKey parses an unencrypted DER encoded PKCS #1, PKCS #8 or
+// SEC 1 private key
+func parseDERPrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("the private key is not PKCS #1, PKCS #8 or SEC 1 encoded")
+}
+
+// parsePKCS12 decodes the private key and the certificate chain in the PKCS
+// #12 data
+func parsePKCS12(data []byte, password string) (tls.Certificate, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	var key crypto.PrivateKey
+	var certs []*x509.Certificate
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+
+			if err != nil {
+				return tls.Certificate{}, err
+			}
+			certs = append(certs, cert)
+		case "PRIVATE KEY":
+			// the key is PKCS #1 or SEC 1 encoded in spite of the block type
+			if key, err = parseDERPrivateKey(block.Bytes); err != nil {
+				return tls.Certificate{}, err
+			}
+		}
+	}
+
+	if key == nil {
+		return tls.Certificate{}, errors.New("there is no private key")
+	}
+	return keyPair(key, certs)
+}
+
+// keyPair pairs the key with the certificates, putting the certificate of the
+// key first, as the Leaf, and the others after it in their original order
+func keyPair(key crypto.PrivateKey, certs []*x509.Certificate) (tls.Certificate, error) {
+	signer, ok := key.(crypto.Signer)
+
+	if !ok {
+		return tls.Certificate{}, fmt.Errorf("the %T private key cannot be used for TLS", key)
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	certificate := tls.Certificate{PrivateKey: key}
+	var chain [][]byte
+
+	for _, cert := range certs {
+		if certificate.Leaf == nil && bytes.Equal(publicKey, cert.RawSubjectPublicKeyInfo) {
+			certificate.Leaf = cert
+		} else {
+			chain = append(chain, cert.Raw)
+		}
+	}
+
+	if certificate.Leaf == nil {
+		return tls.Certificate{}, errors.New("there is no certificate for the private key")
+	}
+	certificate.Certificate = append([][]byte{certificate.Leaf.Raw}, chain...)
+	return certificate, nil
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// SecretExpiration is when the password of a secret expires, according to the
+// expiration rules of its template, and when a change of it was last
+// attempted. The DaysUntilExpiration is nil if the secret does not expire.
+// The Secret Server computes the expiration, so it is not sent when a secret
+// is written.
+type SecretExpiration struct {
+	ExpirationDate Time
+	// LastPasswordChangeAttempt is when a change of the password was last
+	// attempted, whether or not it succeeded. It is not when the password last
+	// changed, which the Secret Server does not report.
+	LastPasswordChangeAttempt Time
+	DaysUntilExpiration       *int
+}
+
+// Expires returns when the secret expires, and a boolean indicating whether it
+// expires at all. If only the DaysUntilExpiration is known, it expires at the
+// start of that day.
+func (e SecretExpiration) Expires() (time.Time, bool) {
+	switch {
+	case !e.ExpirationDate.IsZero():
+		return e.ExpirationDate.Time, true
+	case e.DaysUntilExpiration != nil:
+		year, month, day := time.Now().Date()
+		return time.Date(year, month, day+*e.DaysUntilExpiration, 0, 0, 0, 0, time.Local), true
+	}
+	return time.Time{}, false
+}
+
+// ExpiringSecrets lists the secrets that match the filter and expire within
+// the window from now, including those that have expired, soonest first. The
+// filter's ExpiringWithinDays is set from the window, and its Paging applies
+// to the search, before secrets outside of the window are left out. The
+// window must not be negative.
+func (s Server) ExpiringSecrets(within time.Duration, filter SecretFilter) ([]SecretSummary, error) {
+	if within < 0 {
+		return nil, fmt.Errorf("the window of expiring secrets, %s, is negative", within)
+	}
+	filter.ExpiringWithinDays = int(math.Ceil(within.Hours() / 24))
+
+	secrets, err := s.Secrets(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(within)
+	expiring := make([]SecretSummary, 0, len(secrets))
+	expires := make(map[int]time.Time, len(secrets))
+
+	for _, secret := range secrets {
+		if at, ok := secret.Expires(); ok && !at.After(cutoff) {
+			expiring = append(expiring, secret)
+			expires[secret.ID] = at
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expires[expiring[i].ID].Before(expires[expiring[j].ID])
+	})
+	return expiring, nil
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestExpiringSecrets tests that ExpiringSecrets filters the search by days
+// and returns the secrets within the window, soonest first
+func TestExpiringSecrets(t *testing.T) {
+	soon := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/secrets/" || r.URL.Query().Get("filter.expiringWithinDays") != "7" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"Records":[
+			{"ID":1,"Name":"Later","DaysUntilExpiration":5},
+			{"ID":2,"Name":"Never","DaysUntilExpiration":null},
+			{"ID":3,"Name":"Soon","ExpirationDate":"%s"},
+			{"ID":4,"Name":"Expired","DaysUntilExpiration":-3},
+			{"ID":5,"Name":"Beyond","DaysUntilExpiration":30}
+		]}`, soon)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	secrets, err := tss.ExpiringSecrets(7*24*time.Hour, SecretFilter{})
+	if err != nil {
+		t.Error("calling server.ExpiringSecrets:", err)
+		return
+	}
+
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	validate("expiring secrets", "[Expired Soon Later]", fmt.Sprint(names), t)
+
+	if _, err := tss.ExpiringSecrets(-time.Hour, SecretFilter{}); err == nil {
+		t.Error("expected an error for a negative window")
+	}
+}
+
+// TestWriteSecretOmitsExpiration tests that the expiration of a secret, which
+// the Secret Server computes, is not sent when the secret is written
+func TestWriteSecretOmitsExpiration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/secret-templates/6":
+			fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/secrets/1":
+			var body map[string]interface{}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error("parsing the request body:", err)
+			}
+			validate("name", "Test", body["Name"], t)
+			for _, key := range []string{"ExpirationDate", "LastPasswordChangeAttempt", "DaysUntilExpiration"} {
+				if _, ok := body[key]; ok {
+					t.Errorf("expected %s not to be sent", key)
+				}
+			}
+			fallthrough
+		case r.Method == "GET" && r.URL.Path == "/api/v1/secrets/1":
+			fmt.Fprint(w, `{"ID":1,"Name":"Test","SecretTemplateID":6,"DaysUntilExpiration":3}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	days := 3
+	secret := Secret{ID: 1, Name: "Test", SecretTemplateID: 6}
+	secret.ExpirationDate = Time{time.Now()}
+	secret.LastPasswordChangeAttempt = Time{time.Now()}
+	secret.DaysUntilExpiration = &days
+
+	if _, err := tss.UpdateSecret(secret); err != nil {
+		t.Error("calling server.UpdateSecret:", err)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SecretFieldHistory is a historic value of a field of a secret. The Secret
+// Server hides the ItemValue, leaving it null, when the user may not see
+// previous values; Hidden is then true.
+type SecretFieldHistory struct {
+	SecretItemHistoryID, UserID int
+	UserDisplayName, ItemValue  string
+	Date                        Time
+	Hidden                      bool `json:"-"`
+}
+
+// FieldValueHiddenError is returned by RestoreSecretField when the historic
+// value to restore was hidden from the user
+type FieldValueHiddenError struct {
+	Slug string
+	Date Time
+}
+
+func (e *FieldValueHiddenError) Error() string {
+	return fmt.Sprintf("the historic value of field '%s' from %s is hidden", e.Slug, e.Date)
+}
+
+// UnmarshalJSON parses a historic value, setting Hidden if the ItemValue is
+// null or missing
+func (h *SecretFieldHistory) UnmarshalJSON(data []byte) error {
+	type history SecretFieldHistory
+
+	value := struct {
+		*history
+		ItemValue *string
+	}{history: (*history)(h)}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	h.ItemValue, h.Hidden = "", value.ItemValue == nil
+	if value.ItemValue != nil {
+		h.ItemValue = *value.ItemValue
+	}
+	return nil
+}
+
+// SecretFieldHistory gets the historic values of the field identified by the given slug on the secret with id, most
+// recent first, paged according to paging.
+func (s Server) SecretFieldHistory(id int, slug string, paging Paging) ([]SecretFieldHistory, error) {
+	var history []SecretFieldHistory
+
+	path := fmt.Sprintf("%d/fields/%s/history", id, slug)
+
+	if err := s.listRecords(resource, path, nil, paging, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// RestoreSecretField sets the field identified by the given slug on the secret with id back to the given historic
+// value, updating the secret as UpdateSecret does, and returns the updated secret. It returns a FieldValueHiddenError
+// if the historic value was hidden from the user.
+func (s Server) RestoreSecretField(id int, slug string, entry SecretFieldHistory) (*Secret, error) {
+	if entry.Hidden {
+		return nil, &FieldValueHiddenError{Slug: slug, Date: entry.Date}
+	}
+
+	secret, err := s.Secret(id)
+
+	if err != nil {
+		return nil, err
+	}
+	for index, field := range secret.Fields {
+		if field.Slug == slug {
+			secret.Fields[index].ItemValue = entry.ItemValue
+			return s.UpdateSecret(*secret)
+		}
+	}
+	return nil, fmt.Errorf("no field with slug '%s' on secret '%s'", slug, secret.Name)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRestoreSecretField tests that historic values, including empty ones, are
+// restored through UpdateSecret and that hidden ones are reported as such
+func TestRestoreSecretField(t *testing.T) {
+	var updates []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/secrets/1/fields/password/history":
+			fmt.Fprint(w, `{"Records":[
+				{"SecretItemHistoryID":3,"ItemValue":null,"Date":"2021-03-01T10:00:00"},
+				{"SecretItemHistoryID":2,"ItemValue":"","Date":"2021-02-01T10:00:00"},
+				{"SecretItemHistoryID":1,"ItemValue":"0ld","Date":"2021-01-01T10:00:00"}]}`)
+		case r.URL.Path == "/api/v1/secret-templates/6":
+			fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/secrets/1":
+			var secret Secret
+
+			if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+				t.Error("parsing the update:", err)
+			}
+			for _, field := range secret.Fields {
+				updates = append(updates, field.Slug+": "+field.ItemValue)
+			}
+			fallthrough
+		case r.Method == "GET" && r.URL.Path == "/api/v1/secrets/1":
+			fmt.Fprint(w, `{"ID":1,"Name":"Test","SecretTemplateID":6,"Items":[{"Slug":"password","IsPassword":true,"ItemValue":"n3w"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	history, err := tss.SecretFieldHistory(1, "password", Paging{})
+	if err != nil {
+		t.Error("calling server.SecretFieldHistory:", err)
+		return
+	}
+	if !validate("history count", 3, len(history), t) {
+		return
+	}
+	validate("hidden", true, history[0].Hidden, t)
+	validate("hidden", false, history[1].Hidden, t)
+	validate("item value", "0ld", history[2].ItemValue, t)
+
+	if _, err := tss.RestoreSecretField(1, "password", history[0]); err == nil {
+		t.Error("expected an error restoring a hidden value")
+	} else if _, ok := err.(*FieldValueHiddenError); !ok {
+		t.Errorf("expected a FieldValueHiddenError, but found %v", err)
+	}
+	for _, entry := range history[1:] {
+		if _, err := tss.RestoreSecretField(1, "password", entry); err != nil {
+			t.Error("calling server.RestoreSecretField:", err)
+		}
+	}
+	validate("updates", "[password:  password: 0ld]", fmt.Sprint(updates), t)
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// secretPolicyResource is the HTTP URL path component for the secret policies resource
+const secretPolicyResource = "secret-policy"
+
+// The names of the secret policy items that govern remote password changing,
+// heartbeat, checkout and approval
+const (
+	PolicyAutoChange       = "Auto Change"
+	PolicyHeartbeat        = "Heartbeat Enabled"
+	PolicyRequireCheckOut  = "Require Check Out"
+	PolicyRequireApproval  = "Require Approval For Access"
+	PolicyCheckOutInterval = "Check Out Interval Minutes"
+)
+
+// The ways in which a secret policy item applies to the secrets it covers
+const (
+	PolicyNotSet   = "NotSet"
+	PolicyDefault  = "Default"
+	PolicyEnforced = "Enforced"
+)
+
+// SecretPolicy is a named set of settings applied to secrets, either to all
+// of the secrets in a folder or to individual secrets
+type SecretPolicy struct {
+	SecretPolicyID                            int
+	SecretPolicyName, SecretPolicyDescription string
+	Active                                    bool
+	SecretPolicyItems                         []SecretPolicyItem
+}
+
+// SecretPolicyItem is a setting of a secret policy. Only the value that
+// suits the setting is set.
+type SecretPolicyItem struct {
+	SecretPolicyItemID             int
+	Name, Description, SectionName string
+	PolicyApplyCode                string
+	ValueBool                      *bool
+	ValueInt                       *int
+	ValueString                    *string
+}
+
+// Item returns the setting with the given name, ignoring case, and a boolean
+// indicating whether the policy has such a setting.
+func (p SecretPolicy) Item(name string) (*SecretPolicyItem, bool) {
+	for _, item := range p.SecretPolicyItems {
+		if strings.EqualFold(name, item.Name) {
+			return &item, true
+		}
+	}
+	return nil, false
+}
+
+// Applies reports whether the setting is applied to the secrets, as a default
+// or enforced
+func (i SecretPolicyItem) Applies() bool {
+	return i.PolicyApplyCode == PolicyDefault || i.PolicyApplyCode == PolicyEnforced
+}
+
+// SecretPolicies lists the secret policies whose names contain searchText, or
+// all of them when it is empty. Inactive policies are included if
+// includeInactive is true. The policies are summaries without items.
+func (s Server) SecretPolicies(searchText string, includeInactive bool) ([]SecretPolicy, error) {
+	var policies []SecretPolicy
+
+	query := url.Values{"filter.includeInactive": {strconv.FormatBool(includeInactive)}}
+	if searchText != "" {
+		query.Set("filter.secretPolicyName", searchText)
+	}
+
+	if err := s.listRecords(secretPolicyResource, "search", query, Paging{}, &policies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// SecretPolicy gets the secret policy with id, including its settings
+func (s Server) SecretPolicy(id int) (*SecretPolicy, error) {
+	policy := new(SecretPolicy)
+
+	if data, err := s.accessResource("GET", secretPolicyResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, policy); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", secretPolicyResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// AssignSecretPolicy applies the secret policy with policyId to the secret
+// with secretId. A policyId of zero makes the secret inherit the policy of its
+// folder instead.
+func (s Server) AssignSecretPolicy(secretId, policyId int) error {
+	input := struct {
+		SecretPolicyID            int `json:",omitempty"`
+		EnableInheritSecretPolicy bool
+	}{policyId, policyId == 0}
+
+	_, err := s.accessResource("PUT", resource, fmt.Sprintf("%d/policy", secretId), input)
+	return err
+}
+
+// AssignFolderSecretPolicy applies the secret policy with policyId to the
+// secrets in the folder with folderId. A policyId of zero makes the folder
+// inherit the policy of its parent folder instead.
+func (s Server) AssignFolderSecretPolicy(folderId, policyId int) error {
+	return s.updateFolder(folderId, map[string]interface{}{
+		"secretPolicyId":      policyId,
+		"inheritSecretPolicy": policyId == 0,
+	})
+}
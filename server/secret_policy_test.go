@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAssignSecretPolicy tests what AssignSecretPolicy and
+// AssignFolderSecretPolicy send to assign a policy and to inherit one
+func TestAssignSecretPolicy(t *testing.T) {
+	var updates []map[string]interface{}
+	requests := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/secrets/1/policy":
+			requests[r.URL.Path] = string(body)
+		case r.Method == "GET" && r.URL.Path == "/api/v1/folders/5":
+			fmt.Fprint(w, `{"id":5,"folderName":"Ops","SecretPolicyId":3,"InheritSecretPolicy":false}`)
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/folders/5":
+			var update map[string]interface{}
+			if err := json.Unmarshal(body, &update); err != nil {
+				t.Error("parsing the update:", err)
+			}
+			updates = append(updates, update)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	if err := tss.AssignSecretPolicy(1, 4); err != nil {
+		t.Error("calling server.AssignSecretPolicy:", err)
+	}
+	validate("policy", `{"SecretPolicyID":4,"EnableInheritSecretPolicy":false}`, requests["/api/v1/secrets/1/policy"], t)
+
+	if err := tss.AssignSecretPolicy(1, 0); err != nil {
+		t.Error("calling server.AssignSecretPolicy:", err)
+	}
+	validate("inherited policy", `{"EnableInheritSecretPolicy":true}`, requests["/api/v1/secrets/1/policy"], t)
+
+	for _, policyId := range []int{4, 0} {
+		if err := tss.AssignFolderSecretPolicy(5, policyId); err != nil {
+			t.Error("calling server.AssignFolderSecretPolicy:", err)
+		}
+	}
+	if !validate("folder updates", 2, len(updates), t) {
+		return
+	}
+	for i, expected := range []struct {
+		policyId float64
+		inherit  bool
+	}{{4, false}, {0, true}} {
+		validate("folder name", "Ops", updates[i]["folderName"], t)
+		validate("secret policy ID", expected.policyId, updates[i]["secretPolicyId"], t)
+		validate("inherit secret policy", expected.inherit, updates[i]["inheritSecretPolicy"], t)
+		validate("setting count", 4, len(updates[i]), t)
+	}
+}
+
+// TestSecretPolicyItem tests that SecretPolicy.Item finds settings by name
+// whatever their case
+func TestSecretPolicyItem(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/secret-policy/4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"SecretPolicyID":4,"SecretPolicyName":"Rotate","Active":true,"SecretPolicyItems":[
+			{"SecretPolicyItemID":1,"Name":"Auto Change","PolicyApplyCode":"Enforced","ValueBool":true},
+			{"SecretPolicyItemID":2,"Name":"Check Out Interval Minutes","PolicyApplyCode":"NotSet","ValueInt":30}]}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	policy, err := tss.SecretPolicy(4)
+	if err != nil {
+		t.Error("calling server.SecretPolicy:", err)
+		return
+	}
+
+	if item, ok := policy.Item("auto change"); !ok {
+		t.Errorf("expected the policy to have the setting '%s'", PolicyAutoChange)
+	} else {
+		validate("item ID", 1, item.SecretPolicyItemID, t)
+		validate("applies", true, item.Applies(), t)
+		validate("value", true, item.ValueBool != nil && *item.ValueBool, t)
+	}
+	if item, ok := policy.Item(PolicyCheckOutInterval); !ok {
+		t.Errorf("expected the policy to have the setting '%s'", PolicyCheckOutInterval)
+	} else {
+		validate("applies", false, item.Applies(), t)
+		validate("value", 30, *item.ValueInt, t)
+	}
+	if _, ok := policy.Item(PolicyHeartbeat); ok {
+		t.Errorf("expected the policy not to have the setting '%s'", PolicyHeartbeat)
+	}
+}
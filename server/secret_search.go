@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// SecretSummary is a secret as it is listed by a search, without its fields
+type SecretSummary struct {
+	ID, FolderID, SiteID, SecretTemplateID int
+	Name, SecretTemplateName               string
+	Active, CheckedOut, AutoChangeEnabled  bool
+	SecretExpiration
+}
+
+// SecretFilter selects the secrets whose names contain the SearchText, in the
+// folder with FolderID, and its sub-folders if IncludeSubFolders is true, and
+// based on the template with SecretTemplateID. ExpiringWithinDays selects the
+// secrets that expire within that many days, or have expired. Zero values
+// leave the filter open. Inactive (deleted) secrets are included if
+// IncludeInactive is true.
+type SecretFilter struct {
+	Paging
+	SearchText                         string
+	FolderID, SecretTemplateID         int
+	IncludeSubFolders, IncludeInactive bool
+	ExpiringWithinDays                 int
+}
+
+// query returns the filter as query parameters
+func (f SecretFilter) query() url.Values {
+	query := url.Values{"filter.includeInactive": {strconv.FormatBool(f.IncludeInactive)}}
+
+	if f.SearchText != "" {
+		query.Set("filter.searchText", f.SearchText)
+	}
+	if f.FolderID != 0 {
+		query.Set("filter.folderId", strconv.Itoa(f.FolderID))
+		query.Set("filter.includeSubFolders", strconv.FormatBool(f.IncludeSubFolders))
+	}
+	if f.SecretTemplateID != 0 {
+		query.Set("filter.secretTemplateId", strconv.Itoa(f.SecretTemplateID))
+	}
+	if f.ExpiringWithinDays != 0 {
+		query.Set("filter.expiringWithinDays", strconv.Itoa(f.ExpiringWithinDays))
+	}
+	return query
+}
+
+// Secrets searches for the secrets that match the filter, paged according to
+// the filter
+func (s Server) Secrets(filter SecretFilter) ([]SecretSummary, error) {
+	var secrets []SecretSummary
+
+	if err := s.listRecords(resource, "", filter.query(), filter.Paging, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
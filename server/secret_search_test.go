@@ -0,0 +1,38 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSecrets tests that the search filter is passed as query parameters
+func TestSecrets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		if query.Get("filter.searchText") != "db" || query.Get("filter.folderId") != "6" ||
+			query.Get("filter.includeInactive") != "true" {
+			fmt.Fprint(w, `{"Records":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"Records":[{"ID":1,"Name":"db-admin","Active":false}]}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	secrets, err := tss.Secrets(SecretFilter{SearchText: "db", FolderID: 6, IncludeInactive: true})
+	if err != nil {
+		t.Error("calling server.Secrets:", err)
+		return
+	}
+	if validate("secret count", 1, len(secrets), t) {
+		validate("secret name", "db-admin", secrets[0].Name, t)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 // templateResource is the HTTP URL path component for the secret templates resource
@@ -15,17 +17,38 @@ type SecretTemplate struct {
 	Name   string
 	ID     int
 	Fields []SecretTemplateField
+
+	// indexes into Fields by slug and by field ID, built for cached templates
+	fieldsBySlug map[string]int
+	fieldsByID   map[int]int
 }
 
 // SecretTemplateField is a field in the secret template
 type SecretTemplateField struct {
 	SecretTemplateFieldID                                   int
+	PasswordRequirementID                                   int `json:",omitempty"`
 	FieldSlugName, DisplayName, Description, Name, ListType string
 	IsFile, IsList, IsNotes, IsPassword, IsRequired, IsUrl  bool
 }
 
-// SecretTemplate gets the secret template with id from the Secret Server of the given tenant
+// SecretTemplate gets the secret template with id from the Secret Server of the given tenant. Templates are cached by
+// the Server until they are invalidated.
 func (s Server) SecretTemplate(id int) (*SecretTemplate, error) {
+	if template, ok := s.templates.get(id); ok {
+		return template, nil
+	}
+
+	template, err := s.fetchSecretTemplate(id)
+
+	if err != nil {
+		return nil, err
+	}
+	s.templates.put(template)
+	return template.copy(), nil
+}
+
+// fetchSecretTemplate gets the secret template with id from the Secret Server, bypassing the cache
+func (s Server) fetchSecretTemplate(id int) (*SecretTemplate, error) {
 	secretTemplate := new(SecretTemplate)
 
 	if data, err := s.accessResource("GET", templateResource, strconv.Itoa(id), nil); err == nil {
@@ -40,6 +63,99 @@ func (s Server) SecretTemplate(id int) (*SecretTemplate, error) {
 	return secretTemplate, nil
 }
 
+// SecretTemplates lists the secret templates whose names contain searchText, or
+// all of them when it is empty. The templates are summaries without fields.
+func (s Server) SecretTemplates(searchText string) ([]SecretTemplate, error) {
+	var templates []SecretTemplate
+
+	query := url.Values{}
+	if searchText != "" {
+		query.Set("filter.searchText", searchText)
+	}
+
+	if err := s.listRecords(templateResource, "", query, Paging{}, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// SecretTemplateByName gets the secret template with the given name, ignoring
+// case, and a boolean indicating whether there is such a template.
+func (s Server) SecretTemplateByName(name string) (*SecretTemplate, bool, error) {
+	if template, ok := s.templates.getByName(name); ok {
+		return template, true, nil
+	}
+
+	templates, err := s.SecretTemplates(name)
+
+	if err != nil {
+		return nil, false, err
+	}
+	for _, template := range templates {
+		if strings.EqualFold(name, template.Name) {
+			secretTemplate, err := s.SecretTemplate(template.ID)
+			return secretTemplate, err == nil, err
+		}
+	}
+	log.Printf("[DEBUG] no secret template named '%s'", name)
+	return nil, false, nil
+}
+
+// CreateSecretTemplate creates a secret template with the name and fields of
+// the given template and returns the template that was created.
+func (s Server) CreateSecretTemplate(template SecretTemplate) (*SecretTemplate, error) {
+	secretTemplate := new(SecretTemplate)
+
+	if data, err := s.accessResource("POST", templateResource, "/", template); err == nil {
+		if err = json.Unmarshal(data, secretTemplate); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s: %q", templateResource, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return secretTemplate, nil
+}
+
+// CreateSecretTemplateField adds the field to the secret template with the
+// given id and returns the field that was created.
+func (s Server) CreateSecretTemplateField(templateId int, field SecretTemplateField) (*SecretTemplateField, error) {
+	defer s.InvalidateSecretTemplate(templateId)
+	return s.writeSecretTemplateField("POST", fmt.Sprintf("%d/fields", templateId), field)
+}
+
+// UpdateSecretTemplateField updates the field, identified by its
+// SecretTemplateFieldID, on the secret template with the given id.
+func (s Server) UpdateSecretTemplateField(templateId int, field SecretTemplateField) (*SecretTemplateField, error) {
+	defer s.InvalidateSecretTemplate(templateId)
+	return s.writeSecretTemplateField("PUT", fmt.Sprintf("%d/fields/%d", templateId, field.SecretTemplateFieldID), field)
+}
+
+func (s Server) writeSecretTemplateField(method, path string, field SecretTemplateField) (*SecretTemplateField, error) {
+	writtenField := new(SecretTemplateField)
+
+	if data, err := s.accessResource(method, templateResource, path, field); err == nil {
+		if err = json.Unmarshal(data, writtenField); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", templateResource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return writtenField, nil
+}
+
+// DisableSecretTemplateField disables the field with the given field id on the
+// secret template with the given id. Secret Server keeps disabled fields so
+// that existing secrets retain their values.
+func (s Server) DisableSecretTemplateField(templateId, fieldId int) error {
+	defer s.InvalidateSecretTemplate(templateId)
+	_, err := s.accessResource("DELETE", templateResource, fmt.Sprintf("%d/fields/%d", templateId, fieldId), nil)
+	return err
+}
+
 // GeneratePassword generates and returns a password for the secret field identified by the given slug on the given
 // template. The password adheres to the password requirements associated with the field. NOTE: this should only be
 // used with fields whose IsPassword property is true.
@@ -47,29 +163,42 @@ func (s Server) GeneratePassword(slug string, template *SecretTemplate) (string,
 
 	fieldId, found := template.FieldSlugToId(slug)
 
-	if ! found {
-		log.Printf("[ERROR] the alias '%s' does not identify a field on the template named '%s'", slug, template.Name)
+	if !found {
+		return "", errUnknownSlug(slug, template)
 	}
 	path := fmt.Sprintf("generate-password/%d", fieldId)
 
 	if data, err := s.accessResource("POST", templateResource, path, nil); err == nil {
-		passwordWithQuotes := string(data)
-		return passwordWithQuotes[1:len(passwordWithQuotes) - 1], nil
+		var password string
+
+		if err = json.Unmarshal(data, &password); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", templateResource, path, data)
+			return "", err
+		}
+		return password, nil
 	} else {
 		return "", err
 	}
 }
 
+// errUnknownSlug is the error for a slug that does not identify a field on the template
+func errUnknownSlug(slug string, template *SecretTemplate) error {
+	return fmt.Errorf("the alias '%s' does not identify a field on the template named '%s'", slug, template.Name)
+}
+
 // FieldIdToSlug returns the shorthand alias (aka: "slug") of the field with the given field ID, and a boolean
 // indicating whether the given ID actually identifies a field for the secret template.
 func (s SecretTemplate) FieldIdToSlug(fieldId int) (string, bool) {
+	// the index is only a hint, as the fields may have changed since it was built
+	if index, found := s.fieldsByID[fieldId]; found && index < len(s.Fields) && s.Fields[index].SecretTemplateFieldID == fieldId {
+		return s.Fields[index].FieldSlugName, true
+	}
 	for _, field := range s.Fields {
 		if fieldId == field.SecretTemplateFieldID {
-			log.Printf("[TRACE] template field with slug '%s' matches the given ID '%d'", field.FieldSlugName, fieldId)
 			return field.FieldSlugName, true
 		}
 	}
-	log.Printf("[ERROR] no matching template field with id '%d' in template '%s'", fieldId, s.Name)
+	log.Printf("[DEBUG] no matching template field with id '%d' in template '%s'", fieldId, s.Name)
 	return "", false
 }
 
@@ -86,12 +215,16 @@ func (s SecretTemplate) FieldSlugToId(slug string) (int, bool) {
 // GetField returns the field with the given shorthand alias (aka: "slug"), and a boolean indicating whether the given
 // slug actually identifies a field for the secret template .
 func (s SecretTemplate) GetField(slug string) (*SecretTemplateField, bool) {
+	// the index is only a hint, as the fields may have changed since it was built
+	if index, found := s.fieldsBySlug[slug]; found && index < len(s.Fields) && s.Fields[index].FieldSlugName == slug {
+		field := s.Fields[index]
+		return &field, true
+	}
 	for _, field := range s.Fields {
 		if slug == field.FieldSlugName {
-			log.Printf("[TRACE] template field with ID '%d' matches the given slug '%s'", field.SecretTemplateFieldID, slug)
 			return &field, true
 		}
 	}
-	log.Printf("[ERROR] no matching template field with slug '%s' in template '%s'", slug, s.Name)
+	log.Printf("[DEBUG] no matching template field with slug '%s' in template '%s'", slug, s.Name)
 	return nil, false
 }
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -60,3 +63,113 @@ func TestSecretTemplate(t *testing.T) {
 		}
 	}
 }
+
+// TestSecretTemplateByName tests SecretTemplates and SecretTemplateByName
+func TestSecretTemplateByName(t *testing.T) {
+	tss, err := initServer()
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	id := initIntegerFromEnv("TSS_TEMPLATE_ID", t)
+	if id < 0 {
+		return
+	}
+
+	template, err := tss.SecretTemplate(id)
+	if err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+		return
+	}
+
+	templates, err := tss.SecretTemplates(template.Name)
+	if err != nil {
+		t.Error("calling server.SecretTemplates:", err)
+		return
+	}
+	if len(templates) == 0 {
+		t.Errorf("expected SecretTemplates to list the template named '%s'", template.Name)
+	}
+
+	byName, found, err := tss.SecretTemplateByName(template.Name)
+	if err != nil {
+		t.Error("calling server.SecretTemplateByName:", err)
+		return
+	}
+	if !found {
+		t.Errorf("expected to find the template named '%s'", template.Name)
+		return
+	}
+	validate("template id", id, byName.ID, t)
+	validate("template field count", len(template.Fields), len(byName.Fields), t)
+}
+
+// TestSecretTemplateWrites tests the methods and paths of the calls that write
+// secret templates and their fields, and that writing a field invalidates the
+// cached template
+func TestSecretTemplateWrites(t *testing.T) {
+	var requests []string
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && r.URL.Path == "/api/v1/secret-templates/6" {
+			fetches++
+			fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch r.Method {
+		case "POST", "PUT":
+			if r.URL.Path == "/api/v1/secret-templates/" {
+				fmt.Fprint(w, `{"ID":7,"Name":"Database"}`)
+			} else {
+				fmt.Fprint(w, `{"SecretTemplateFieldID":62,"FieldSlugName":"host"}`)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	if template, err := tss.CreateSecretTemplate(SecretTemplate{Name: "Database"}); err != nil {
+		t.Error("calling server.CreateSecretTemplate:", err)
+	} else {
+		validate("template id", 7, template.ID, t)
+	}
+
+	writes := []func() error{
+		func() error {
+			_, err := tss.CreateSecretTemplateField(6, SecretTemplateField{FieldSlugName: "host"})
+			return err
+		},
+		func() error {
+			_, err := tss.UpdateSecretTemplateField(6, SecretTemplateField{SecretTemplateFieldID: 62, FieldSlugName: "host"})
+			return err
+		},
+		func() error {
+			return tss.DisableSecretTemplateField(6, 62)
+		},
+	}
+	for i, write := range writes {
+		if _, err := tss.SecretTemplate(6); err != nil {
+			t.Error("calling server.SecretTemplate:", err)
+			return
+		}
+		if err := write(); err != nil {
+			t.Error("writing the template field:", err)
+		}
+		validate("template fetches", i+1, fetches, t)
+	}
+	if _, err := tss.SecretTemplate(6); err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+	}
+	validate("template fetches", len(writes)+1, fetches, t)
+
+	validate("requests", "[POST /api/v1/secret-templates/ POST /api/v1/secret-templates/6/fields "+
+		"PUT /api/v1/secret-templates/6/fields/62 DELETE /api/v1/secret-templates/6/fields/62]", fmt.Sprint(requests), t)
+}
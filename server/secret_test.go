@@ -110,6 +110,19 @@ func TestSecretCRUD(t *testing.T) {
 	// Test read of the deleted secret fails
 	s, err := tss.Secret(sc.ID)
 	if s != nil { t.Errorf("deleted secret with id '%d' returned from read", sc.ID) }
+
+	// Test read of the deleted secret including inactive secrets
+	s, err = tss.SecretIncludingInactive(sc.ID)
+	if err != nil { t.Error("calling server.SecretIncludingInactive:", err); return }
+	if !validate("deleted secret active", false, s.Active, t) { return }
+
+	// Test the reactivation of the deleted secret
+	s, err = tss.ReactivateSecret(sc.ID)
+	if err != nil { t.Error("calling server.ReactivateSecret:", err); return }
+	if !validate("reactivated secret active", true, s.Active, t) { return }
+
+	err = tss.DeactivateSecret(sc.ID)
+	if err != nil { t.Error("calling server.DeactivateSecret:", err); return }
 }
 
 func initServer() (*Server, error) {
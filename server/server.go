@@ -2,21 +2,23 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"strings"
+	"time"
 )
 
 const (
-	cloudBaseURLTemplate string = "https://%s.secretservercloud.%s/"
-	defaultAPIPathURI    string = "/api/v1"
-	defaultTokenPathURI  string = "/oauth2/token"
-	defaultTLD           string = "com"
+	cloudBaseURLTemplate string        = "https://%s.secretservercloud.%s/"
+	defaultAPIPathURI    string        = "/api/v1"
+	defaultTokenPathURI  string        = "/oauth2/token"
+	defaultTLD           string        = "com"
+	defaultRecovery      time.Duration = time.Minute
 )
 
 // UserCredential holds the username and password that the API should use to
@@ -25,20 +27,32 @@ type UserCredential struct {
 	Username, Password string
 }
 
-// Configuration settings for the API
+// Configuration settings for the API. When no Authenticator is set, the
+// Credentials are used with the OAuth2 password grant. Requests fail over
+// between the ServerURL and ServerURLs according to the FailoverPolicy, and
+// unhealthy endpoints are probed every RecoveryInterval.
 type Configuration struct {
 	Credentials                                      UserCredential
+	Authenticator                                    Authenticator `json:"-"`
 	ServerURL, TLD, Tenant, apiPathURI, tokenPathURI string
+	ServerURLs                                       []string
+	FailoverPolicy                                   FailoverPolicy
+	RecoveryInterval                                 time.Duration
 }
 
 // Server provides access to secrets stored in Thycotic Secret Server
 type Server struct {
 	Configuration
+	grants    *grantCache
+	endpoints *endpointPool
+	templates *templateRegistry
 }
 
 // New returns an initialized Secrets object
 func New(config Configuration) (*Server, error) {
-	if config.ServerURL == "" && config.Tenant == "" || config.ServerURL != "" && config.Tenant != "" {
+	hasURL := config.ServerURL != "" || len(config.ServerURLs) > 0
+
+	if !hasURL && config.Tenant == "" || hasURL && config.Tenant != "" {
 		return nil, fmt.Errorf("either ServerURL or Tenant must be set")
 	}
 	if config.TLD == "" {
@@ -52,19 +66,68 @@ func New(config Configuration) (*Server, error) {
 		config.tokenPathURI = defaultTokenPathURI
 	}
 	config.tokenPathURI = strings.Trim(config.tokenPathURI, "/")
-	return &Server{config}, nil
-}
+	if config.Authenticator == nil {
+		config.Authenticator = PasswordGrant{
+			Username: config.Credentials.Username,
+			Password: config.Credentials.Password,
+		}
+	}
+	if config.RecoveryInterval <= 0 {
+		config.RecoveryInterval = defaultRecovery
+	}
 
-// urlFor is the URL for the given resource and path
-func (s Server) urlFor(resource, path string) string {
-	var baseURL string
+	var baseURLs []string
+
+	switch {
+	case config.Tenant != "":
+		baseURLs = []string{fmt.Sprintf(cloudBaseURLTemplate, config.Tenant, config.TLD)}
+	case config.ServerURL != "":
+		baseURLs = append([]string{config.ServerURL}, config.ServerURLs...)
+	default:
+		baseURLs = config.ServerURLs
+	}
 
+	return &Server{
+		Configuration: config,
+		grants:        new(grantCache),
+		endpoints:     newEndpointPool(baseURLs, config.FailoverPolicy),
+		templates:     newTemplateRegistry(),
+	}, nil
+}
+
+// baseURLs are the base URLs of the endpoints in the order in which they
+// should be tried
+func (s Server) baseURLs() []string {
+	if s.endpoints != nil {
+		return s.endpoints.candidates()
+	}
 	if s.ServerURL == "" {
-		baseURL = fmt.Sprintf(cloudBaseURLTemplate, s.Tenant, s.TLD)
-	} else {
-		baseURL = s.ServerURL
+		return []string{fmt.Sprintf(cloudBaseURLTemplate, s.Tenant, s.TLD)}
+	}
+	return []string{s.ServerURL}
+}
+
+// allBaseURLs are the base URLs of all of the endpoints in the order in which
+// they are configured. Unlike baseURLs, it does not advance the rotation.
+func (s Server) allBaseURLs() []string {
+	if s.endpoints != nil {
+		return s.endpoints.all()
 	}
+	return s.baseURLs()
+}
 
+// preferredBaseURL is the base URL of the endpoint that the next request
+// would try first. Unlike baseURLs, it does not advance the rotation.
+func (s Server) preferredBaseURL() string {
+	if s.endpoints != nil {
+		return s.endpoints.preferred()
+	}
+	return s.baseURLs()[0]
+}
+
+// endpointURLFor is the URL for the given resource and path on the endpoint
+// with the given base URL
+func (s Server) endpointURLFor(baseURL, resource, path string) string {
 	switch {
 	case resource == "token":
 		return fmt.Sprintf("%s/%s", baseURL, s.tokenPathURI)
@@ -80,9 +143,24 @@ func (s Server) urlFor(resource, path string) string {
 // accessResource uses the accessToken to access the API resource.
 // It assumes an appropriate combination of method, resource, path and input.
 func (s Server) accessResource(method, resource, path string, input interface{}) ([]byte, error) {
+	return s.accessResourceContext(context.Background(), method, resource, path, input)
+}
+
+// accessResourceContext accesses the API resource, as accessResource does, for
+// as long as the context allows
+func (s Server) accessResourceContext(ctx context.Context, method, resource, path string, input interface{}) ([]byte, error) {
 	switch resource {
 	case "secrets":
 	case "secret-templates":
+	case oauthExpirationResource:
+	case passwordRequirementResource:
+	case auditResource:
+	case secretPermissionResource, folderPermissionResource, folderResource:
+	case userResource, groupResource, roleResource:
+	case versionResource:
+	case distributedEngineResource:
+	case secretPolicyResource:
+	case accessRequestResource:
 	default:
 		message := "unknown resource"
 
@@ -90,43 +168,88 @@ func (s Server) accessResource(method, resource, path string, input interface{})
 		return nil, fmt.Errorf(message)
 	}
 
-	body := bytes.NewBuffer([]byte{})
+	var body []byte
 
 	if input != nil {
 		if data, err := json.Marshal(input); err == nil {
-			body = bytes.NewBuffer(data)
+			body = data
 		} else {
 			log.Print("[ERROR] marshaling the request body to JSON:", err)
 			return nil, err
 		}
 	}
 
-	req, err := http.NewRequest(method, s.urlFor(resource, path), body)
+	return s.withFailover(ctx, method, func(baseURL string) ([]byte, error) {
+		return s.accessEndpoint(ctx, baseURL, method, resource, path, body)
+	})
+}
+
+// writeResource sends the input to the API resource and parses the response,
+// which describes what was written, into output.
+func (s Server) writeResource(method, resource, path string, input, output interface{}) error {
+	data, err := s.accessResource(method, resource, path, input)
 
 	if err != nil {
-		log.Printf("[ERROR] creating req: %s /%s/%s: %s", method, resource, path, err)
-		return nil, err
+		return err
+	}
+	if err = json.Unmarshal(data, output); err != nil {
+		log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, strings.Trim(path, "/"), data)
+		return err
 	}
+	return nil
+}
 
-	accessToken, err := s.getAccessToken()
+// accessEndpoint accesses the API resource on the endpoint with the given
+// base URL, sending the body as JSON, for as long as the context allows.
+func (s Server) accessEndpoint(ctx context.Context, baseURL, method, resource, path string, body []byte) ([]byte, error) {
+	return s.sendAuthorized(baseURL, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, s.endpointURLFor(baseURL, resource, path), bytes.NewBuffer(body))
 
-	if err != nil {
-		log.Print("[ERROR] error getting accessToken:", err)
-		return nil, err
-	}
+		if err != nil {
+			log.Printf("[ERROR] creating req: %s /%s/%s: %s", method, resource, path, err)
+			return nil, err
+		}
 
-	req.Header.Add("Authorization", "Bearer "+accessToken)
+		switch method {
+		case "POST", "PUT", "PATCH":
+			req.Header.Set("Content-Type", "application/json")
+		}
 
-	switch method {
-	case "POST", "PUT", "PATCH":
-		req.Header.Set("Content-Type", "application/json")
-	}
+		log.Printf("[DEBUG] calling %s %s", method, req.URL.String())
+		return req, nil
+	})
+}
+
+// sendAuthorized sends the request made by newRequest to the endpoint with the
+// given base URL with its access token. If the endpoint rejects the token, for
+// instance because it was revoked, the cached grant is dropped and the request
+// is made and sent once more with a new token.
+func (s Server) sendAuthorized(baseURL string, newRequest func() (*http.Request, error)) ([]byte, error) {
+	for retried := false; ; retried = true {
+		req, err := newRequest()
+
+		if err != nil {
+			return nil, err
+		}
 
-	log.Printf("[DEBUG] calling %s %s", method, req.URL.String())
+		accessToken, err := s.getAccessToken(baseURL)
 
-	data, _, err := handleResponse((&http.Client{}).Do(req))
+		if err != nil {
+			log.Print("[ERROR] error getting accessToken:", err)
+			return nil, err
+		}
+
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+
+		data, _, err := handleResponse((&http.Client{}).Do(req))
 
-	return data, err
+		if responseError, ok := err.(*ResponseError); ok && responseError.StatusCode == http.StatusUnauthorized && !retried {
+			log.Printf("[INFO] the access token for %s was rejected; authenticating again", baseURL)
+			s.grants.remove(s.endpointURLFor(baseURL, "token", ""))
+			continue
+		}
+		return data, err
+	}
 }
 
 // uploadFile uploads the file described in the given fileField to the
@@ -135,13 +258,6 @@ func (s Server) uploadFile(secretId int, fileField SecretField) error {
 	body := bytes.NewBuffer([]byte{})
 	path := fmt.Sprintf("%d/fields/%s", secretId, fileField.Slug)
 
-	// Fetch the access token
-	accessToken, err := s.getAccessToken()
-	if err != nil {
-		log.Print("[ERROR] error getting accessToken:", err)
-		return err
-	}
-
 	// Create the multipart form
 	multipartWriter := multipart.NewWriter(body)
 	form, err := multipartWriter.CreateFormFile("file", fileField.Filename)
@@ -157,44 +273,53 @@ func (s Server) uploadFile(secretId int, fileField SecretField) error {
 		return err
 	}
 
-	// Make the request
-	req, err := http.NewRequest("PUT", s.urlFor(resource, path), body)
-	if err != nil {
-		return err
-	}
-	req.Header.Add("Authorization", "Bearer " + accessToken)
-	req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
-	log.Printf("[DEBUG] uploading file with PUT %s", req.URL.String())
-	_, _, err = handleResponse((&http.Client{}).Do(req))
+	_, err = s.withFailover(context.Background(), "PUT", func(baseURL string) ([]byte, error) {
+		return s.sendAuthorized(baseURL, func() (*http.Request, error) {
+			req, err := http.NewRequest("PUT", s.endpointURLFor(baseURL, resource, path), bytes.NewReader(body.Bytes()))
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
+			log.Printf("[DEBUG] uploading file with PUT %s", req.URL.String())
+			return req, nil
+		})
+	})
 
 	return err
 }
 
-// getAccessToken gets an OAuth2 Access Grant and returns the token
-// endpoint and get an accessGrant.
-func (s Server) getAccessToken() (string, error) {
-	body := strings.NewReader(url.Values{
-		"username":   {s.Credentials.Username},
-		"password":   {s.Credentials.Password},
-		"grant_type": {"password"},
-	}.Encode())
-	data, _, err := handleResponse(http.Post(s.urlFor("token", ""), "application/x-www-form-urlencoded", body))
+// getAccessToken returns the token of a cached access grant for the endpoint
+// with the given base URL or gets a new one from the Authenticator.
+func (s Server) getAccessToken(baseURL string) (string, error) {
+	grant, err := s.getGrant(baseURL)
 
 	if err != nil {
-		log.Print("[ERROR] grant response error:", err)
 		return "", err
 	}
+	return grant.AccessToken, nil
+}
 
-	grant := struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		TokenType    string `json:"token_type"`
-		ExpiresIn    int    `json:"expires_in"`
-	}{}
+// getGrant returns the cached access grant for the endpoint with the given
+// base URL or gets a new one from the Authenticator and caches it.
+func (s Server) getGrant(baseURL string) (*AccessGrant, error) {
+	tokenURL := s.endpointURLFor(baseURL, "token", "")
 
-	if err = json.Unmarshal(data, &grant); err != nil {
-		log.Print("[ERROR] parsing grant response:", err)
-		return "", err
+	if grant, ok := s.grants.get(tokenURL); ok {
+		return grant, nil
 	}
-	return grant.AccessToken, nil
+	if s.Authenticator == nil {
+		return nil, fmt.Errorf("no Authenticator is configured")
+	}
+
+	grant, err := s.Authenticator.Grant(tokenURL)
+
+	if err != nil {
+		log.Print("[ERROR] authenticating:", err)
+		return nil, err
+	}
+	if grant.ExpiresIn > 0 {
+		grant.Expires = time.Now().Add(time.Duration(grant.ExpiresIn) * time.Second)
+	}
+	s.grants.put(tokenURL, grant)
+	return grant, nil
 }
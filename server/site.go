@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// distributedEngineResource is the HTTP URL path component for the distributed engine resource
+const distributedEngineResource = "distributed-engine"
+
+// EngineOnline is the ConnectionStatus of an engine that is connected
+const EngineOnline = "Online"
+
+// Site is a distributed engine site, the SiteID of which determines where a
+// secret's remote operations run
+type Site struct {
+	SiteID   int
+	SiteName string
+	Active   bool
+}
+
+// Engine is a distributed engine in a site
+type Engine struct {
+	EngineID, SiteID                                 int
+	FriendlyName, ConnectionStatus, ActivationStatus string
+	LastConnected                                    Time
+}
+
+// Online reports whether the engine is connected
+func (e Engine) Online() bool {
+	return e.ConnectionStatus == EngineOnline
+}
+
+// Sites lists the distributed engine sites. Inactive sites are included if
+// includeInactive is true.
+func (s Server) Sites(includeInactive bool) ([]Site, error) {
+	var sites []Site
+
+	query := url.Values{"filter.includeInactive": {strconv.FormatBool(includeInactive)}}
+
+	if err := s.listRecords(distributedEngineResource, "sites", query, Paging{}, &sites); err != nil {
+		return nil, err
+	}
+	return sites, nil
+}
+
+// SiteByName gets the active site with the given name, ignoring case, and a
+// boolean indicating whether there is such a site.
+func (s Server) SiteByName(name string) (*Site, bool, error) {
+	sites, err := s.Sites(false)
+
+	if err != nil {
+		return nil, false, err
+	}
+	for _, site := range sites {
+		if strings.EqualFold(name, site.SiteName) {
+			return &site, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// SiteEngines lists the distributed engines in the site with id and their
+// connection status
+func (s Server) SiteEngines(id int) ([]Engine, error) {
+	var engines []Engine
+
+	query := url.Values{"filter.siteId": {strconv.Itoa(id)}}
+
+	if err := s.listRecords(distributedEngineResource, "engines", query, Paging{}, &engines); err != nil {
+		return nil, err
+	}
+	return engines, nil
+}
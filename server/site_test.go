@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSites tests Sites and SiteByName
+func TestSites(t *testing.T) {
+	tss, err := initServer()
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	id := initIntegerFromEnv("TSS_SITE_ID", t)
+	if id < 0 {
+		return
+	}
+
+	sites, err := tss.Sites(false)
+	if err != nil {
+		t.Error("calling server.Sites:", err)
+		return
+	}
+
+	for _, site := range sites {
+		if site.SiteID != id {
+			continue
+		}
+		byName, found, err := tss.SiteByName(site.SiteName)
+		if err != nil {
+			t.Error("calling server.SiteByName:", err)
+		} else if !found {
+			t.Errorf("expected to find the site named '%s'", site.SiteName)
+		} else {
+			validate("site id", id, byName.SiteID, t)
+		}
+		return
+	}
+	t.Errorf("expected Sites to list the site with id '%d'", id)
+}
+
+// TestSiteRequests tests the paths and filters with which sites and engines
+// are listed, and that SiteByName reports a missing site as not found
+func TestSiteRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch r.URL.Path {
+		case "/api/v1/distributed-engine/sites":
+			if query.Get("filter.includeInactive") == "true" {
+				fmt.Fprint(w, `{"Records":[{"SiteID":1,"SiteName":"Local","Active":true},{"SiteID":2,"SiteName":"Retired"}]}`)
+			} else if validate("include inactive", "false", query.Get("filter.includeInactive"), t) {
+				fmt.Fprint(w, `{"Records":[{"SiteID":1,"SiteName":"Local","Active":true}]}`)
+			}
+		case "/api/v1/distributed-engine/engines":
+			validate("site id", "3", query.Get("filter.siteId"), t)
+			fmt.Fprint(w, `{"Records":[
+				{"EngineID":5,"SiteID":3,"FriendlyName":"engine-a","ConnectionStatus":"Online"},
+				{"EngineID":6,"SiteID":3,"FriendlyName":"engine-b","ConnectionStatus":"Offline"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	if sites, err := tss.Sites(true); err != nil {
+		t.Error("calling server.Sites:", err)
+	} else {
+		validate("site count", 2, len(sites), t)
+	}
+
+	if site, found, err := tss.SiteByName("local"); err != nil || !found {
+		t.Error("expected SiteByName to find the site named 'Local':", err)
+	} else {
+		validate("site id", 1, site.SiteID, t)
+	}
+	if site, found, err := tss.SiteByName("Retired"); err != nil || found || site != nil {
+		t.Errorf("expected SiteByName not to find the inactive site, but found %v (%t): %v", site, found, err)
+	}
+	if _, found, err := tss.SiteByName("Missing"); err != nil || found {
+		t.Errorf("expected SiteByName not to find the site named 'Missing' (%t): %v", found, err)
+	}
+
+	engines, err := tss.SiteEngines(3)
+	if err != nil {
+		t.Error("calling server.SiteEngines:", err)
+		return
+	}
+	if validate("engine count", 2, len(engines), t) {
+		validate("online", true, engines[0].Online(), t)
+		validate("online", false, engines[1].Online(), t)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The SSH key algorithms that Secret Server can generate keys for
+const (
+	SSHKeyRSA     = "RSA"
+	SSHKeyDSA     = "DSA"
+	SSHKeyECDSA   = "ECDSA"
+	SSHKeyEd25519 = "Ed25519"
+)
+
+// SSHKeyOptions describe the SSH key pair to generate. The Algorithm defaults
+// to RSA and the Bits to the server's default for the algorithm. The private
+// key is encrypted with the Passphrase, or with a generated one if
+// GeneratePassphrase is true.
+type SSHKeyOptions struct {
+	Algorithm          string
+	Bits               int    `json:",omitempty"`
+	Passphrase         string `json:",omitempty"`
+	GeneratePassphrase bool
+}
+
+// SSHKey is a generated SSH key pair. The PrivateKey is in PEM format and the
+// PublicKey in OpenSSH authorized_keys format.
+type SSHKey struct {
+	Algorithm                         string `json:"-"`
+	PrivateKey, PublicKey, Passphrase string
+}
+
+// SSHKeyFields are the slugs of the fields of an SSH key template that hold
+// the private key and public key files and the passphrase. Empty slugs are
+// skipped.
+type SSHKeyFields struct {
+	PrivateKey, PublicKey, Passphrase string
+}
+
+// DefaultSSHKeyFields are the slugs of the SSH key fields of the built-in Unix
+// Account (SSH) templates
+var DefaultSSHKeyFields = SSHKeyFields{
+	PrivateKey: "private-key",
+	PublicKey:  "public-key",
+	Passphrase: "private-key-passphrase",
+}
+
+// GenerateSSHKey has the Secret Server generate an SSH key pair
+func (s Server) GenerateSSHKey(options SSHKeyOptions) (*SSHKey, error) {
+	if options.Algorithm == "" {
+		options.Algorithm = SSHKeyRSA
+	}
+
+	key := &SSHKey{Algorithm: options.Algorithm}
+
+	if err := s.writeResource("POST", resource, "generate-ssh-keys", options, key); err != nil {
+		return nil, err
+	}
+	if key.PrivateKey == "" {
+		return nil, fmt.Errorf("no %s private key was generated", options.Algorithm)
+	}
+	if key.Passphrase == "" {
+		key.Passphrase = options.Passphrase
+	}
+	return key, nil
+}
+
+// SetSSHKey uploads the private and public keys into the file fields of the
+// secret with id, and sets the passphrase field, as named by fields. Empty
+// keys and an empty passphrase are skipped.
+func (s Server) SetSSHKey(id int, key SSHKey, fields SSHKeyFields) error {
+	baseName := "id_" + strings.ToLower(key.Algorithm)
+	if key.Algorithm == "" {
+		baseName = "id_rsa"
+	}
+
+	if fields.PrivateKey != "" && key.PrivateKey != "" {
+		privateKey := SecretField{Slug: fields.PrivateKey, Filename: baseName, ItemValue: key.PrivateKey}
+
+		if err := s.uploadFile(id, privateKey); err != nil {
+			return err
+		}
+	}
+	if fields.PublicKey != "" && key.PublicKey != "" {
+		publicKey := SecretField{Slug: fields.PublicKey, Filename: baseName + ".pub", ItemValue: key.PublicKey}
+
+		if err := s.uploadFile(id, publicKey); err != nil {
+			return err
+		}
+	}
+	if fields.Passphrase != "" && key.Passphrase != "" {
+		if err := s.patchField(id, fields.Passphrase, key.Passphrase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProvisionSSHKey generates an SSH key pair and sets it on the secret with id,
+// as GenerateSSHKey and SetSSHKey do, returning the key that was set.
+func (s Server) ProvisionSSHKey(id int, options SSHKeyOptions, fields SSHKeyFields) (*SSHKey, error) {
+	key, err := s.GenerateSSHKey(options)
+
+	if err != nil {
+		return nil, err
+	}
+	if err = s.SetSSHKey(id, *key, fields); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSetSSHKey tests that SetSSHKey uploads the keys with file names that
+// suit the algorithm, sets the passphrase and skips what is empty
+func TestSetSSHKey(t *testing.T) {
+	uploads := map[string]string{}
+	var patches []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				t.Error("parsing the upload:", err)
+				return
+			}
+			defer file.Close()
+
+			data, _ := ioutil.ReadAll(file)
+			uploads[r.URL.Path] = header.Filename + ": " + string(data)
+		case "PATCH":
+			var patch struct {
+				Data struct {
+					SecretFields []struct {
+						Slug  string
+						Dirty bool
+						Value string
+					}
+				}
+			}
+			if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+				t.Error("parsing the patch:", err)
+			}
+			validate("patch path", "/api/v1/secrets/1/general", r.URL.Path, t)
+			for _, field := range patch.Data.SecretFields {
+				patches = append(patches, field.Slug+": "+field.Value)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	key := SSHKey{Algorithm: SSHKeyEd25519, PrivateKey: "private", PublicKey: "ssh-ed25519 public", Passphrase: "s3cr3t"}
+
+	if err := tss.SetSSHKey(1, key, DefaultSSHKeyFields); err != nil {
+		t.Error("calling server.SetSSHKey:", err)
+		return
+	}
+	validate("upload count", 2, len(uploads), t)
+	validate("private key", "id_ed25519: private", uploads["/api/v1/secrets/1/fields/private-key"], t)
+	validate("public key", "id_ed25519.pub: ssh-ed25519 public", uploads["/api/v1/secrets/1/fields/public-key"], t)
+	if validate("patch count", 1, len(patches), t) {
+		validate("passphrase", "private-key-passphrase: s3cr3t", patches[0], t)
+	}
+
+	uploads, patches = map[string]string{}, nil
+
+	if err := tss.SetSSHKey(1, SSHKey{PublicKey: "ssh-rsa public"}, DefaultSSHKeyFields); err != nil {
+		t.Error("calling server.SetSSHKey:", err)
+		return
+	}
+	validate("upload count", 1, len(uploads), t)
+	validate("public key", "id_rsa.pub: ssh-rsa public", uploads["/api/v1/secrets/1/fields/public-key"], t)
+	validate("patch count", 0, len(patches), t)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"strings"
+	"sync"
+)
+
+// templateRegistry caches secret templates by ID and by name. It is shared by
+// all copies of the Server and safe for concurrent use.
+type templateRegistry struct {
+	mutex     sync.RWMutex
+	templates map[int]*SecretTemplate
+	ids       map[string]int
+}
+
+func newTemplateRegistry() *templateRegistry {
+	return &templateRegistry{
+		templates: make(map[int]*SecretTemplate),
+		ids:       make(map[string]int),
+	}
+}
+
+// get returns a copy of the cached template with the given id
+func (r *templateRegistry) get(id int) (*SecretTemplate, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if template, ok := r.templates[id]; ok {
+		return template.copy(), true
+	}
+	return nil, false
+}
+
+// getByName returns a copy of the cached template with the given name,
+// ignoring case
+func (r *templateRegistry) getByName(name string) (*SecretTemplate, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mutex.RLock()
+	id, ok := r.ids[strings.ToLower(name)]
+	r.mutex.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return r.get(id)
+}
+
+// put indexes the fields of the template and caches it
+func (r *templateRegistry) put(template *SecretTemplate) {
+	if r == nil {
+		return
+	}
+	template.index()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if cached, ok := r.templates[template.ID]; ok {
+		delete(r.ids, strings.ToLower(cached.Name))
+	}
+	r.templates[template.ID] = template
+	r.ids[strings.ToLower(template.Name)] = template.ID
+}
+
+// remove drops the template with the given id from the cache
+func (r *templateRegistry) remove(id int) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if cached, ok := r.templates[id]; ok {
+		delete(r.ids, strings.ToLower(cached.Name))
+		delete(r.templates, id)
+	}
+}
+
+// clear drops all of the templates from the cache
+func (r *templateRegistry) clear() {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.templates = make(map[int]*SecretTemplate)
+	r.ids = make(map[string]int)
+}
+
+// InvalidateSecretTemplate drops the secret template with the given id from
+// the cache, so that it is fetched again the next time it is needed
+func (s Server) InvalidateSecretTemplate(id int) {
+	s.templates.remove(id)
+}
+
+// InvalidateSecretTemplates drops all of the secret templates from the cache
+func (s Server) InvalidateSecretTemplates() {
+	s.templates.clear()
+}
+
+// index builds the maps from slug and from field ID to the fields
+func (t *SecretTemplate) index() {
+	t.fieldsBySlug = make(map[string]int, len(t.Fields))
+	t.fieldsByID = make(map[int]int, len(t.Fields))
+
+	for i, field := range t.Fields {
+		t.fieldsBySlug[field.FieldSlugName] = i
+		t.fieldsByID[field.SecretTemplateFieldID] = i
+	}
+}
+
+// copy returns a copy of the template with its own fields and indexes, so
+// that callers cannot modify a cached template
+func (t *SecretTemplate) copy() *SecretTemplate {
+	template := *t
+	template.Fields = append([]SecretTemplateField(nil), t.Fields...)
+	template.index()
+	return &template
+}
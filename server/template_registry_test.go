@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTemplateRegistry tests that secret templates are cached by ID and by
+// name until they are invalidated
+func TestTemplateRegistry(t *testing.T) {
+	fetches := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			fmt.Fprint(w, `{"access_token":"t0k3n","token_type":"bearer","expires_in":1200}`)
+		case "/api/v1/secret-templates/6":
+			fetches++
+			fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[
+				{"SecretTemplateFieldID":60,"FieldSlugName":"username"},
+				{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		template, err := tss.SecretTemplate(6)
+		if err != nil {
+			t.Error("calling server.SecretTemplate:", err)
+			return
+		}
+		if id, found := template.FieldSlugToId("password"); !found || id != 61 {
+			t.Errorf("expected the slug 'password' to map to 61, but found %d (%t)", id, found)
+		}
+		if slug, found := template.FieldIdToSlug(60); !found || slug != "username" {
+			t.Errorf("expected the field id 60 to map to 'username', but found '%s' (%t)", slug, found)
+		}
+		template.Fields[0].FieldSlugName = "modified"
+	}
+	validate("template fetches", 1, fetches, t)
+
+	if template, found, err := tss.SecretTemplateByName("password"); err != nil || !found {
+		t.Error("expected SecretTemplateByName to find the cached template:", err)
+	} else {
+		validate("template id", 6, template.ID, t)
+	}
+
+	tss.InvalidateSecretTemplate(6)
+	if _, err := tss.SecretTemplate(6); err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+		return
+	}
+	validate("template fetches after invalidation", 2, fetches, t)
+}
+
+// TestTemplateRegistryCopy tests that the fields of a cached template are
+// looked up correctly after the template returned is changed
+func TestTemplateRegistryCopy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ID":6,"Name":"Password","Fields":[
+			{"SecretTemplateFieldID":60,"FieldSlugName":"username"},
+			{"SecretTemplateFieldID":61,"FieldSlugName":"password","IsPassword":true}]}`)
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	template, err := tss.SecretTemplate(6)
+	if err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+		return
+	}
+	template.Fields = template.Fields[:1]
+	if _, found := template.GetField("password"); found {
+		t.Error("expected no 'password' field after truncating the fields")
+	}
+
+	template, err = tss.SecretTemplate(6)
+	if err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+		return
+	}
+	template.Fields[0], template.Fields[1] = template.Fields[1], template.Fields[0]
+	if field, found := template.GetField("password"); !found {
+		t.Error("expected a 'password' field after reordering the fields")
+	} else {
+		validate("password field id", 61, field.SecretTemplateFieldID, t)
+	}
+	if slug, found := template.FieldIdToSlug(60); !found || slug != "username" {
+		t.Errorf("expected the field id 60 to map to 'username', but found '%s' (%t)", slug, found)
+	}
+	template.Fields[0].FieldSlugName = "secret"
+	if _, found := template.FieldSlugToId("password"); found {
+		t.Error("expected no 'password' field after renaming it")
+	}
+
+	template, err = tss.SecretTemplate(6)
+	if err != nil {
+		t.Error("calling server.SecretTemplate:", err)
+		return
+	}
+	if id, found := template.FieldSlugToId("password"); !found || id != 61 {
+		t.Errorf("expected the cached template to be unchanged, but 'password' maps to %d (%t)", id, found)
+	}
+}
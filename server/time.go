@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// timeLayouts are the layouts of the timestamps returned by Secret Server,
+// which omits the zone of times that are in UTC
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
+
+// Time is a timestamp in a Secret Server response. A null or empty
+// timestamp is the zero Time.
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a Secret Server timestamp
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var value *string
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, *value); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse '%s' as a timestamp", *value)
+}
+
+// MarshalJSON formats the timestamp as RFC 3339, or null if it is zero
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTime tests parsing the timestamps Secret Server returns
+func TestTime(t *testing.T) {
+	expected := time.Date(2020, 7, 1, 13, 45, 30, 0, time.UTC)
+
+	for _, data := range []string{`"2020-07-01T13:45:30"`, `"2020-07-01T13:45:30Z"`, `"2020-07-01T09:45:30-04:00"`} {
+		var parsed Time
+
+		if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+			t.Errorf("parsing %s: %s", data, err)
+		} else if !parsed.Equal(expected) {
+			t.Errorf("expected %s to be %s, but found %s", data, expected, parsed)
+		}
+	}
+
+	var parsed Time
+	if err := json.Unmarshal([]byte(`null`), &parsed); err != nil || !parsed.IsZero() {
+		t.Error("expected null to be the zero Time:", err)
+	}
+	if err := json.Unmarshal([]byte(`"yesterday"`), &parsed); err == nil {
+		t.Error("expected an error parsing an invalid timestamp")
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+	"time"
+)
+
+// The defaults for TLSConfigOptions
+const (
+	defaultTLSRefreshInterval = time.Hour
+	defaultTLSRenewBefore     = 24 * time.Hour
+)
+
+// minimumTLSReloadInterval limits how often a certificate is reloaded when it
+// nears expiry
+const minimumTLSReloadInterval = time.Minute
+
+// tlsReloadTimeout limits how long loading the certificate may take
+const tlsReloadTimeout = time.Minute
+
+// TLSConfigOptions describe where a TLS certificate is kept in a secret and
+// how often it is reloaded. The certificate and key are read from the file
+// fields CertSlug and KeySlug, as Secret.TLSCertificate does, or, if
+// PKCS12Slug is set, from a PKCS #12 file as Secret.PKCS12 does.
+type TLSConfigOptions struct {
+	SecretID                 int
+	CertSlug, KeySlug        string
+	PKCS12Slug, PasswordSlug string
+	// Base is cloned to make the tls.Config, or the defaults are used if it is nil
+	Base *tls.Config
+	// RefreshInterval is how often the certificate is reloaded; it defaults to an hour
+	RefreshInterval time.Duration
+	// RenewBefore is how long before the certificate expires it is reloaded
+	// more often, at most once a minute; it defaults to a day
+	RenewBefore time.Duration
+	// OnError, if set, is called with the error whenever reloading fails. The
+	// previous certificate is used until reloading succeeds.
+	OnError func(error)
+}
+
+// CertificateReloader keeps a TLS certificate that is kept in a secret up to
+// date, reloading it from the Secret Server in the background, as the options
+// describe, until it is closed
+type CertificateReloader struct {
+	server  Server
+	options TLSConfigOptions
+	mutex   sync.RWMutex
+	current *tls.Certificate
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+// ReloadCertificate loads the certificate in a secret and starts reloading it
+// in the background so that it can be rotated without a restart. It returns an
+// error if the certificate cannot be loaded at first. Close the reloader to
+// stop reloading.
+func (s Server) ReloadCertificate(options TLSConfigOptions) (*CertificateReloader, error) {
+	if options.PKCS12Slug == "" && options.CertSlug == "" {
+		return nil, errors.New("either the CertSlug or the PKCS12Slug must be set")
+	}
+	if options.RefreshInterval <= 0 {
+		options.RefreshInterval = defaultTLSRefreshInterval
+	}
+	if options.RenewBefore <= 0 {
+		options.RenewBefore = defaultTLSRenewBefore
+	}
+
+	reloader := &CertificateReloader{server: s, options: options}
+	reloader.ctx, reloader.cancel = context.WithCancel(context.Background())
+
+	if err := reloader.reload(); err != nil {
+		reloader.cancel()
+		return nil, err
+	}
+
+	go reloader.run()
+	return reloader, nil
+}
+
+// Certificate returns the current certificate
+func (r *CertificateReloader) Certificate() *tls.Certificate {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.current
+}
+
+// TLSConfig returns a tls.Config that presents the current certificate, both
+// as a server and as a client. Handshakes never wait for a reload. Any
+// certificates in the Base are left out, as crypto/tls would present them
+// instead of the current certificate.
+func (r *CertificateReloader) TLSConfig() *tls.Config {
+	config := &tls.Config{}
+	if r.options.Base != nil {
+		config = r.options.Base.Clone()
+	}
+	config.Certificates = nil
+	config.NameToCertificate = nil
+	config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return r.Certificate(), nil
+	}
+	config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return r.Certificate(), nil
+	}
+	return config
+}
+
+// Close stops reloading the certificate, canceling a reload in progress. The
+// tls.Config keeps presenting the current one.
+func (r *CertificateReloader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// run reloads the certificate whenever it is due until the reloader is closed
+func (r *CertificateReloader) run() {
+	timer := time.NewTimer(r.delay(time.Now()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if err := r.reload(); err != nil && r.ctx.Err() == nil && r.options.OnError != nil {
+			r.options.OnError(err)
+		}
+		timer.Reset(r.delay(time.Now()))
+	}
+}
+
+// delay returns how long to wait before reloading the certificate: the refresh
+// interval or, once the certificate nears expiry, at least
+// minimumTLSReloadInterval, but never longer than the refresh interval
+func (r *CertificateReloader) delay(now time.Time) time.Duration {
+	delay := r.options.RefreshInterval
+	untilRenewal := r.Certificate().Leaf.NotAfter.Add(-r.options.RenewBefore).Sub(now)
+
+	if untilRenewal < minimumTLSReloadInterval {
+		untilRenewal = minimumTLSReloadInterval
+	}
+	if untilRenewal < delay {
+		delay = untilRenewal
+	}
+	return delay
+}
+
+// reload loads the certificate from the secret, replacing the current one if
+// it loads, unless the reloader is closed first or it takes longer than
+// tlsReloadTimeout
+func (r *CertificateReloader) reload() error {
+	ctx, cancel := context.WithTimeout(r.ctx, tlsReloadTimeout)
+	defer cancel()
+
+	secret, err := r.server.readSecretContext(ctx, r.options.SecretID, false)
+
+	if err != nil {
+		return err
+	}
+
+	var certificate tls.Certificate
+
+	if r.options.PKCS12Slug != "" {
+		certificate, err = secret.PKCS12(r.options.PKCS12Slug, r.options.PasswordSlug)
+	} else {
+		certificate, err = secret.TLSCertificate(r.options.CertSlug, r.options.KeySlug)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.current = &certificate
+	return nil
+}
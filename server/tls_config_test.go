@@ -0,0 +1,203 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCertificateReloader tests that the certificate is reloaded from the
+// secret in the background, that the previous one is kept when reloading
+// fails or hangs, and that reloading stops when the reloader is closed
+func TestCertificateReloader(t *testing.T) {
+	var mutex sync.Mutex
+	var bundle string
+	var hang chan struct{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		value, wait := bundle, hang
+		mutex.Unlock()
+
+		if wait != nil {
+			<-wait
+		}
+		switch r.URL.Path {
+		case "/api/v1/secrets/1":
+			fmt.Fprint(w, `{"ID":1,"Name":"Test","Items":[{"Slug":"bundle","IsFile":true,"FileAttachmentID":1,"Filename":"bundle.pem"}]}`)
+		case "/api/v1/secrets/1/fields/bundle":
+			fmt.Fprint(w, value)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	setBundle := func(value string, wait chan struct{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		bundle, hang = value, wait
+	}
+	// eventually polls the condition until it holds or a second has passed
+	eventually := func(condition func() bool) bool {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if condition() {
+				return true
+			}
+		}
+		return false
+	}
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	if _, err := tss.ReloadCertificate(TLSConfigOptions{SecretID: 1, CertSlug: "bundle"}); err == nil {
+		t.Error("expected an error loading an empty file")
+	}
+
+	certPEM, keyPEM, _ := testKeyPair(t)
+	setBundle(certPEM+keyPEM, nil)
+
+	reloadErrors := make(chan error, 100)
+	reloader, err := tss.ReloadCertificate(TLSConfigOptions{
+		SecretID:        1,
+		CertSlug:        "bundle",
+		RefreshInterval: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case reloadErrors <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Error("calling server.ReloadCertificate:", err)
+		return
+	}
+	defer reloader.Close()
+
+	config := reloader.TLSConfig()
+	first, err := config.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil || first == nil {
+		t.Error("calling GetCertificate:", err)
+		return
+	}
+
+	setBundle("not a certificate", nil)
+	select {
+	case <-reloadErrors:
+	case <-time.After(time.Second):
+		t.Error("expected OnError to be called when reloading fails")
+	}
+	if certificate, err := config.GetClientCertificate(&tls.CertificateRequestInfo{}); err != nil || certificate != first {
+		t.Error("expected the previous certificate when reloading fails:", err)
+	}
+
+	// a handshake does not wait for a reload that hangs
+	hung := make(chan struct{})
+	setBundle(certPEM+keyPEM, hung)
+	time.Sleep(50 * time.Millisecond)
+
+	returned := make(chan *tls.Certificate)
+	go func() {
+		certificate, _ := config.GetCertificate(&tls.ClientHelloInfo{})
+		returned <- certificate
+	}()
+	select {
+	case certificate := <-returned:
+		if certificate != first {
+			t.Error("expected the previous certificate while reloading hangs")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected GetCertificate not to wait for the reload")
+	}
+
+	certPEM, keyPEM, _ = testKeyPair(t)
+	setBundle(certPEM+keyPEM, nil)
+	close(hung)
+
+	if !eventually(func() bool { return reloader.Certificate() != first }) {
+		t.Error("expected the certificate to be reloaded")
+	}
+
+	reloader.Close()
+	time.Sleep(50 * time.Millisecond)
+	last := reloader.Certificate()
+
+	certPEM, keyPEM, _ = testKeyPair(t)
+	setBundle(certPEM+keyPEM, nil)
+	time.Sleep(50 * time.Millisecond)
+
+	if reloader.Certificate() != last {
+		t.Error("expected no reloads after Close")
+	}
+}
+
+// TestCertificateReloaderClose tests that the certificates of the Base are
+// left out of the tls.Config and that Close cancels a reload in progress
+func TestCertificateReloaderClose(t *testing.T) {
+	certPEM, keyPEM, _ := testKeyPair(t)
+	var requests int32
+	hung, canceled := make(chan struct{}), make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/secrets/1":
+			if atomic.AddInt32(&requests, 1) > 1 {
+				close(hung)
+				<-r.Context().Done()
+				close(canceled)
+				return
+			}
+			fmt.Fprint(w, `{"ID":1,"Name":"Test","Items":[{"Slug":"bundle","IsFile":true,"FileAttachmentID":1,"Filename":"bundle.pem"}]}`)
+		case "/api/v1/secrets/1/fields/bundle":
+			fmt.Fprint(w, certPEM+keyPEM)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	reloader, err := tss.ReloadCertificate(TLSConfigOptions{
+		SecretID:        1,
+		CertSlug:        "bundle",
+		RefreshInterval: 10 * time.Millisecond,
+		Base:            &tls.Config{Certificates: []tls.Certificate{{}}, ServerName: "example.local"},
+	})
+	if err != nil {
+		t.Error("calling server.ReloadCertificate:", err)
+		return
+	}
+
+	config := reloader.TLSConfig()
+	validate("certificates", 0, len(config.Certificates), t)
+	validate("server name", "example.local", config.ServerName, t)
+
+	select {
+	case <-hung:
+	case <-time.After(time.Second):
+		t.Error("expected the certificate to be reloaded")
+		return
+	}
+	reloader.Close()
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("expected Close to cancel the reload")
+	}
+}
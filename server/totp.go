@@ -0,0 +1,50 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits int   = 6
+	totpPeriod int64 = 30
+)
+
+// TOTP returns the RFC 6238 time-based one-time password for the given
+// base32-encoded secret at time t, using HMAC-SHA1, 30 second steps and six
+// digits, as authenticator apps do.
+func TOTP(secret string, t time.Time) (string, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+
+	if err != nil {
+		return "", fmt.Errorf("the TOTP secret is not valid base32: %s", err)
+	}
+	return totp(key, t, totpDigits), nil
+}
+
+// totp computes the one-time password with the given number of digits from
+// the raw key
+func totp(key []byte, t time.Time, digits int) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/totpPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, as per RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%modulus)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// TestTOTP tests TOTP against the SHA1 test vectors in RFC 6238 appendix B
+func TestTOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for seconds, expected := range vectors {
+		validate("TOTP", expected, totp(key, time.Unix(seconds, 0), 8), t)
+	}
+
+	secret := base32.StdEncoding.EncodeToString(key)
+	code, err := TOTP(secret, time.Unix(59, 0))
+	if err != nil {
+		t.Error("calling TOTP:", err)
+		return
+	}
+	validate("six digit TOTP", "287082", code, t)
+
+	if _, err := TOTP("not base32!", time.Now()); err == nil {
+		t.Error("expected an error for a secret that is not base32")
+	}
+}
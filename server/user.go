@@ -0,0 +1,236 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// userResource is the HTTP URL path component for the users resource
+	userResource = "users"
+	// groupResource is the HTTP URL path component for the groups resource
+	groupResource = "groups"
+	// roleResource is the HTTP URL path component for the roles resource
+	roleResource = "roles"
+)
+
+// User represents a Secret Server user. The Password is only sent when a
+// local user is created.
+type User struct {
+	ID                                  int `json:",omitempty"`
+	UserName, DisplayName, EmailAddress string
+	Password                            string `json:",omitempty"`
+	DomainID                            int    `json:",omitempty"`
+	Enabled, IsLockedOut                bool
+}
+
+// Group represents a Secret Server group of users
+type Group struct {
+	ID       int `json:",omitempty"`
+	Name     string
+	DomainID int `json:",omitempty"`
+	Enabled  bool
+}
+
+// GroupMember is a user who is a member of a group
+type GroupMember struct {
+	GroupID, UserID       int
+	UserName, DisplayName string
+}
+
+// Role is a set of application permissions that can be assigned to users
+type Role struct {
+	ID      int
+	Name    string
+	Enabled bool
+}
+
+// Users lists the users whose names contain searchText, or all of them when it
+// is empty, paged according to paging. Inactive users are included if
+// includeInactive is true.
+func (s Server) Users(searchText string, includeInactive bool, paging Paging) ([]User, error) {
+	var users []User
+
+	query := url.Values{"filter.includeInactive": {strconv.FormatBool(includeInactive)}}
+	if searchText != "" {
+		query.Set("filter.searchText", searchText)
+	}
+
+	if err := s.listRecords(userResource, "", query, paging, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// User gets the user with id
+func (s Server) User(id int) (*User, error) {
+	user := new(User)
+
+	if data, err := s.accessResource("GET", userResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, user); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", userResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CurrentUser gets the user that the Server is acting as
+func (s Server) CurrentUser() (*User, error) {
+	user := new(User)
+
+	if data, err := s.accessResource("GET", userResource, "current", nil); err == nil {
+		if err = json.Unmarshal(data, user); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/current: %q", userResource, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CurrentUserRoles lists the roles assigned to the user that the Server is acting as
+func (s Server) CurrentUserRoles() ([]Role, error) {
+	user, err := s.CurrentUser()
+
+	if err != nil {
+		return nil, err
+	}
+	return s.UserRoles(user.ID)
+}
+
+// CreateUser creates the user and returns the user as created
+func (s Server) CreateUser(user User) (*User, error) {
+	written := new(User)
+
+	if err := s.writeResource("POST", userResource, "/", user, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// UpdateUser updates the user with the user's ID and returns the user as updated
+func (s Server) UpdateUser(user User) (*User, error) {
+	written := new(User)
+
+	user.Password = ""
+	if err := s.writeResource("PUT", userResource, strconv.Itoa(user.ID), user, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// DeactivateUser deactivates the user with id. Secret Server does not delete
+// users, so that the audit trail remains intact.
+func (s Server) DeactivateUser(id int) error {
+	_, err := s.accessResource("DELETE", userResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// Groups lists the groups whose names contain searchText, or all of them when
+// it is empty, paged according to paging
+func (s Server) Groups(searchText string, paging Paging) ([]Group, error) {
+	var groups []Group
+
+	query := url.Values{}
+	if searchText != "" {
+		query.Set("filter.searchText", searchText)
+	}
+
+	if err := s.listRecords(groupResource, "", query, paging, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Group gets the group with id
+func (s Server) Group(id int) (*Group, error) {
+	group := new(Group)
+
+	if data, err := s.accessResource("GET", groupResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, group); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", groupResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// CreateGroup creates the group and returns the group as created
+func (s Server) CreateGroup(group Group) (*Group, error) {
+	written := new(Group)
+
+	if err := s.writeResource("POST", groupResource, "/", group, written); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
+
+// GroupMembers lists the members of the group with id, paged according to paging
+func (s Server) GroupMembers(id int, paging Paging) ([]GroupMember, error) {
+	var members []GroupMember
+
+	if err := s.listRecords(groupResource, fmt.Sprintf("%d/users", id), nil, paging, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddGroupMember adds the user with userId to the group with groupId
+func (s Server) AddGroupMember(groupId, userId int) error {
+	input := struct{ UserID int }{userId}
+
+	_, err := s.accessResource("POST", groupResource, fmt.Sprintf("%d/users", groupId), input)
+	return err
+}
+
+// RemoveGroupMember removes the user with userId from the group with groupId
+func (s Server) RemoveGroupMember(groupId, userId int) error {
+	_, err := s.accessResource("DELETE", groupResource, fmt.Sprintf("%d/users/%d", groupId, userId), nil)
+	return err
+}
+
+// Roles lists the roles, paged according to paging
+func (s Server) Roles(paging Paging) ([]Role, error) {
+	var roles []Role
+
+	if err := s.listRecords(roleResource, "", nil, paging, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// UserRoles lists the roles assigned to the user with id
+func (s Server) UserRoles(id int) ([]Role, error) {
+	var roles []Role
+
+	if err := s.listRecords(userResource, fmt.Sprintf("%d/roles", id), nil, Paging{}, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// AssignRole assigns the role with roleId to the user with userId
+func (s Server) AssignRole(userId, roleId int) error {
+	input := struct{ RoleIDs []int }{[]int{roleId}}
+
+	_, err := s.accessResource("POST", userResource, fmt.Sprintf("%d/roles", userId), input)
+	return err
+}
+
+// UnassignRole removes the role with roleId from the user with userId
+func (s Server) UnassignRole(userId, roleId int) error {
+	_, err := s.accessResource("DELETE", userResource, fmt.Sprintf("%d/roles/%d", userId, roleId), nil)
+	return err
+}
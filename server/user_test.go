@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// TestCurrentUser tests CurrentUser
+func TestCurrentUser(t *testing.T) {
+	tss, err := initServer()
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	user, err := tss.CurrentUser()
+	if err != nil {
+		t.Error("calling server.CurrentUser:", err)
+		return
+	}
+	if user.ID == 0 || user.UserName == "" {
+		t.Errorf("expected the current user to have an ID and user name, but found %+v", user)
+	}
+}
+
+// TestUsers tests that Users sends the filter and fetches the users page by
+// page, limited by Paging
+func TestUsers(t *testing.T) {
+	const total = 150
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/api/v1/users/" ||
+			!validate("search text", "ad", query.Get("filter.searchText"), t) ||
+			!validate("include inactive", "true", query.Get("filter.includeInactive"), t) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		skip, _ := strconv.Atoi(query.Get("skip"))
+		take, _ := strconv.Atoi(query.Get("take"))
+
+		var records []User
+		for i := skip; i < skip+take && i < total; i++ {
+			records = append(records, User{ID: i + 1, UserName: fmt.Sprintf("admin%d", i)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Records": records, "Skip": skip, "Take": take, "Total": total, "HasNext": skip+take < total,
+		})
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	users, err := tss.Users("ad", true, Paging{})
+	if err != nil {
+		t.Error("calling server.Users:", err)
+		return
+	}
+	validate("user count", total, len(users), t)
+
+	users, err = tss.Users("ad", true, Paging{Skip: 90, Take: 20})
+	if err != nil {
+		t.Error("calling server.Users:", err)
+		return
+	}
+	validate("paged user count", 20, len(users), t)
+	validate("first paged user", "admin90", users[0].UserName, t)
+}
+
+// TestUserWrites tests that UpdateUser does not send the password and that
+// AddGroupMember and AssignRole send the IDs to the right paths
+func TestUserWrites(t *testing.T) {
+	requests := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests[r.Method+" "+r.URL.Path] = string(body)
+
+		if r.URL.Path == "/api/v1/users/1" {
+			fmt.Fprint(w, `{"ID":1,"UserName":"jdoe","DisplayName":"John Doe"}`)
+		}
+	}))
+	defer ts.Close()
+
+	tss, err := New(Configuration{Authenticator: StaticToken("t0k3n"), ServerURL: ts.URL})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	user, err := tss.UpdateUser(User{ID: 1, UserName: "jdoe", DisplayName: "John Doe", Password: "s3cr3t"})
+	if err != nil {
+		t.Error("calling server.UpdateUser:", err)
+		return
+	}
+	validate("display name", "John Doe", user.DisplayName, t)
+
+	var update map[string]interface{}
+	if err := json.Unmarshal([]byte(requests["PUT /api/v1/users/1"]), &update); err != nil {
+		t.Error("parsing the update:", err)
+	} else if _, ok := update["Password"]; ok {
+		t.Error("expected the password not to be sent")
+	}
+
+	if err := tss.AddGroupMember(2, 1); err != nil {
+		t.Error("calling server.AddGroupMember:", err)
+	}
+	validate("group member", `{"UserID":1}`, requests["POST /api/v1/groups/2/users"], t)
+
+	if err := tss.AssignRole(1, 3); err != nil {
+		t.Error("calling server.AssignRole:", err)
+	}
+	validate("role assignment", `{"RoleIDs":[3]}`, requests["POST /api/v1/users/1/roles"], t)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// versionResource is the HTTP URL path component for the version resource
+const versionResource = "version"
+
+// Version is the version of a Secret Server, e.g. 10.9.000033 is Major 10,
+// Minor 9 and Build 33
+type Version struct {
+	Major, Minor, Build int
+	Raw                 string
+}
+
+// ParseVersion parses a dotted Secret Server version
+func ParseVersion(raw string) (*Version, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ".")
+	numbers := make([]int, 3)
+
+	if len(parts) > len(numbers) {
+		return nil, fmt.Errorf("'%s' is not a version", raw)
+	}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a version", raw)
+		}
+		numbers[i] = number
+	}
+	return &Version{Major: numbers[0], Minor: numbers[1], Build: numbers[2], Raw: raw}, nil
+}
+
+// AtLeast reports whether the version is the given version or later
+func (v Version) AtLeast(major, minor, build int) bool {
+	switch {
+	case v.Major != major:
+		return v.Major > major
+	case v.Minor != minor:
+		return v.Minor > minor
+	}
+	return v.Build >= build
+}
+
+func (v Version) String() string {
+	return v.Raw
+}
+
+// PingResult is the outcome of a Ping. The Latency is that of the health
+// check alone. The Version is only set when the Ping was authenticated.
+type PingResult struct {
+	Healthy bool
+	Latency time.Duration
+	Version *Version
+}
+
+// Version gets the version of the Secret Server
+func (s Server) Version() (*Version, error) {
+	data, err := s.accessResource("GET", versionResource, "", nil)
+
+	if err != nil {
+		return nil, err
+	}
+	return parseVersionResponse(data)
+}
+
+// Ping checks that the preferred endpoint of the Secret Server is reachable
+// and healthy using its unauthenticated health check, and, if authenticated
+// is true, that the Server can authenticate and get the version. It returns
+// a result whenever the endpoint responded, and marks the endpoint healthy or
+// unhealthy accordingly, without changing which endpoint requests go to next.
+func (s Server) Ping(ctx context.Context, authenticated bool) (*PingResult, error) {
+	baseURL := s.preferredBaseURL()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.endpointURLFor(baseURL, healthCheckResource, ""), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	data, _, err := handleResponse((&http.Client{}).Do(req))
+	latency := time.Since(start)
+
+	if err != nil {
+		log.Printf("[ERROR] health check of %s: %s", baseURL, err)
+
+		if isEndpointFailure(err) && ctx.Err() == nil {
+			s.markEndpoint(baseURL, false)
+		}
+		return nil, err
+	}
+
+	health := struct{ Healthy *bool }{}
+	result := &PingResult{Healthy: true, Latency: latency}
+
+	if json.Unmarshal(data, &health) == nil && health.Healthy != nil {
+		result.Healthy = *health.Healthy
+	}
+	s.markEndpoint(baseURL, result.Healthy)
+
+	if authenticated {
+		data, err = s.accessEndpoint(ctx, baseURL, "GET", versionResource, "", nil)
+
+		if err == nil {
+			result.Version, err = parseVersionResponse(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// parseVersionResponse parses the version out of the response from the
+// version resource
+func parseVersionResponse(data []byte) (*Version, error) {
+	response := struct {
+		Model struct{ Version string }
+	}{}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		log.Printf("[ERROR] error parsing response from /%s: %q", versionResource, data)
+		return nil, err
+	}
+	return ParseVersion(response.Model.Version)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestParseVersion tests parsing and comparing versions
+func TestParseVersion(t *testing.T) {
+	version, err := ParseVersion("10.9.000033")
+	if err != nil {
+		t.Error("calling ParseVersion:", err)
+		return
+	}
+	validate("major", 10, version.Major, t)
+	validate("minor", 9, version.Minor, t)
+	validate("build", 33, version.Build, t)
+
+	validate("at least 10.9.33", true, version.AtLeast(10, 9, 33), t)
+	validate("at least 10.8.100", true, version.AtLeast(10, 8, 100), t)
+	validate("at least 10.9.34", false, version.AtLeast(10, 9, 34), t)
+	validate("at least 11.0.0", false, version.AtLeast(11, 0, 0), t)
+
+	if _, err := ParseVersion("ten"); err == nil {
+		t.Error("expected an error parsing 'ten'")
+	}
+}
+
+// TestVersion tests Version
+func TestVersion(t *testing.T) {
+	tss, err := initServer()
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+
+	version, err := tss.Version()
+	if err != nil {
+		t.Error("calling server.Version:", err)
+		return
+	}
+	if version.Major == 0 {
+		t.Errorf("expected a major version, but found '%s'", version)
+	}
+}
+
+// TestPing tests that Ping reports the health and version of the preferred
+// endpoint, marks its health and does not change the rotation
+func TestPing(t *testing.T) {
+	var healthy int32 = 1
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/healthcheck/":
+			fmt.Fprintf(w, `{"Healthy":%t}`, atomic.LoadInt32(&healthy) == 1)
+		case "/api/v1/version/":
+			fmt.Fprint(w, `{"Success":true,"Model":{"Version":"10.9.000033"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer first.Close()
+
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Healthy":true}`)
+	}))
+	defer second.Close()
+
+	tss, err := New(Configuration{
+		Authenticator:  StaticToken("t0k3n"),
+		ServerURLs:     []string{first.URL, second.URL},
+		FailoverPolicy: RoundRobinFailover,
+	})
+	if err != nil {
+		t.Error("configuring the Server:", err)
+		return
+	}
+	defer tss.Close()
+
+	for i := 0; i < 2; i++ {
+		result, err := tss.Ping(context.Background(), true)
+		if err != nil {
+			t.Error("calling server.Ping:", err)
+			return
+		}
+		validate("healthy", true, result.Healthy, t)
+		validate("version", "10.9.000033", result.Version.Raw, t)
+		validate("build", 33, result.Version.Build, t)
+	}
+	validate("next endpoint", first.URL, tss.preferredBaseURL(), t)
+
+	atomic.StoreInt32(&healthy, 0)
+	result, err := tss.Ping(context.Background(), false)
+	if err != nil {
+		t.Error("calling server.Ping:", err)
+		return
+	}
+	validate("healthy", false, result.Healthy, t)
+	if result.Version != nil {
+		t.Error("expected no version from an unauthenticated Ping")
+	}
+	if unhealthy := tss.endpoints.unhealthyURLs(); len(unhealthy) != 1 || unhealthy[0] != first.URL {
+		t.Errorf("expected %s to be marked unhealthy, but found %v", first.URL, unhealthy)
+	}
+}